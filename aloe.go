@@ -8,7 +8,9 @@ import (
 	"github.com/caicloud/aloe/cleaner"
 	"github.com/caicloud/aloe/config"
 	"github.com/caicloud/aloe/framework"
+	"github.com/caicloud/aloe/matcher"
 	"github.com/caicloud/aloe/preset"
+	"github.com/caicloud/aloe/roundtrip"
 	glogutil "github.com/caicloud/aloe/utils/glog"
 )
 
@@ -60,6 +62,24 @@ func RegisterCleaner(cs ...cleaner.Cleaner) error {
 	return f.RegisterCleaner(cs...)
 }
 
+// RegisterMatcher registers custom special matcher to the default framework
+func RegisterMatcher(name string, factory matcher.Factory) error {
+	assertAloeInit()
+	return f.RegisterMatcher(name, factory)
+}
+
+// EnableCookieJar enables cookie jar of client in default framework
+func EnableCookieJar(name string, scope roundtrip.CookieScope) error {
+	assertAloeInit()
+	return f.EnableCookieJar(name, scope)
+}
+
+// ServeHandler serves requests to host with name by handler in default framework
+func ServeHandler(name string, h http.Handler) error {
+	assertAloeInit()
+	return f.ServeHandler(name, h)
+}
+
 // CustomizeClient config http client of default framework
 func CustomizeClient(name string, client *http.Client) {
 	assertAloeInit()
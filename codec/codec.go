@@ -0,0 +1,109 @@
+package codec
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+)
+
+// Decoder decodes body of http response to a generic tree
+// The tree MUST be the same as the result of json.Unmarshal into interface{},
+// i.e. it only contains map[string]interface{}, []interface{}, string, float64, bool and nil
+type Decoder interface {
+	// Decode decodes body to a generic tree
+	Decode(body []byte) (interface{}, error)
+}
+
+// DecoderFunc is a function which implements Decoder
+type DecoderFunc func(body []byte) (interface{}, error)
+
+// Decode implements Decoder
+func (f DecoderFunc) Decode(body []byte) (interface{}, error) {
+	return f(body)
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]Decoder{}
+	suffixes     = map[string]Decoder{}
+)
+
+// Builtin decoders
+var (
+	// JSON decodes json
+	JSON Decoder = jsonDecoder{}
+	// XML decodes xml
+	XML Decoder = xmlDecoder{}
+	// YAML decodes yaml
+	YAML Decoder = yamlDecoder{}
+	// Form decodes application/x-www-form-urlencoded
+	Form Decoder = formDecoder{}
+	// Text decodes plain text to a string, or json if it is valid json
+	Text Decoder = textDecoder{}
+)
+
+func init() {
+	mustRegister("application/json", JSON)
+	mustRegister("application/xml", XML)
+	mustRegister("text/xml", XML)
+	for _, t := range []string{"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"} {
+		mustRegister(t, YAML)
+	}
+	mustRegister("application/x-www-form-urlencoded", Form)
+	mustRegister("text/plain", Text)
+
+	// structured syntax suffixes, e.g. application/merge-patch+json
+	suffixes["+json"] = JSON
+	suffixes["+xml"] = XML
+	suffixes["+yaml"] = YAML
+}
+
+func mustRegister(mediaType string, d Decoder) {
+	if err := Register(mediaType, d); err != nil {
+		panic(err)
+	}
+}
+
+// Register registers decoder of media type, e.g. application/json
+func Register(mediaType string, d Decoder) error {
+	if d == nil {
+		return fmt.Errorf("can't register codec %v: decoder is nil", mediaType)
+	}
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "" {
+		return fmt.Errorf("can't register codec with empty media type")
+	}
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := registry[mediaType]; ok {
+		return fmt.Errorf("can't register codec %v: already exists", mediaType)
+	}
+	registry[mediaType] = d
+	return nil
+}
+
+// ForContentType returns decoder of content type
+// JSON decoder is returned if content type is empty or unknown
+func ForContentType(contentType string) Decoder {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	if d, ok := registry[mediaType]; ok {
+		return d
+	}
+	if i := strings.LastIndex(mediaType, "+"); i != -1 {
+		if d, ok := suffixes[mediaType[i:]]; ok {
+			return d
+		}
+	}
+	return JSON
+}
+
+// Decode decodes body by decoder of content type
+func Decode(contentType string, body []byte) (interface{}, error) {
+	return ForContentType(contentType).Decode(body)
+}
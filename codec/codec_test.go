@@ -0,0 +1,105 @@
+package codec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	cases := []struct {
+		desc        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{
+			"json",
+			"application/json; charset=utf-8",
+			`{"a": [1, "b"]}`,
+			`{"a": [1, "b"]}`,
+		},
+		{
+			"unknown content type falls back to json",
+			"",
+			`{"a": 1}`,
+			`{"a": 1}`,
+		},
+		{
+			"json suffix",
+			"application/merge-patch+json",
+			`{"a": 1}`,
+			`{"a": 1}`,
+		},
+		{
+			"xml",
+			"application/xml",
+			`<?xml version="1.0"?><a x="1"><b>2</b><b>3</b><c y="4">5</c><d/></a>`,
+			`{"a": {"@x": "1", "b": ["2", "3"], "c": {"@y": "4", "#text": "5"}, "d": ""}}`,
+		},
+		{
+			"xml nested",
+			"text/xml",
+			`<list><item><id>1</id></item><item><id>2</id></item></list>`,
+			`{"list": {"item": [{"id": "1"}, {"id": "2"}]}}`,
+		},
+		{
+			"yaml",
+			"application/x-yaml",
+			"a:\n- 1\n- b\n",
+			`{"a": [1, "b"]}`,
+		},
+		{
+			"form",
+			"application/x-www-form-urlencoded",
+			`a=1&b=2&b=3`,
+			`{"a": "1", "b": ["2", "3"]}`,
+		},
+		{
+			"text",
+			"text/plain; charset=utf-8",
+			`hello`,
+			`"hello"`,
+		},
+		{
+			"json served as text",
+			"text/plain; charset=utf-8",
+			`{"a": 1}`,
+			`{"a": 1}`,
+		},
+		{
+			"json array served as text",
+			"text/plain; charset=utf-8",
+			` [1, "a"]`,
+			`[1, "a"]`,
+		},
+		{
+			"number served as text",
+			"text/plain; charset=utf-8",
+			`42`,
+			`"42"`,
+		},
+		{
+			"literal served as text",
+			"text/plain; charset=utf-8",
+			`null`,
+			`"null"`,
+		},
+	}
+	for _, c := range cases {
+		obj, err := Decode(c.contentType, []byte(c.body))
+		require.NoError(t, err, c.desc)
+		var expected interface{}
+		require.NoError(t, json.Unmarshal([]byte(c.expected), &expected), c.desc)
+		assert.Equal(t, expected, obj, c.desc)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	require.NoError(t, Register("application/vnd.test", Text))
+	assert.Error(t, Register("application/vnd.test", JSON))
+	assert.Equal(t, Text, ForContentType("application/vnd.test; charset=utf-8"))
+	assert.Equal(t, JSON, ForContentType("application/unknown"))
+}
@@ -0,0 +1,155 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+type jsonDecoder struct{}
+
+// Decode implements Decoder
+func (jsonDecoder) Decode(body []byte) (interface{}, error) {
+	var obj interface{}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+type yamlDecoder struct{}
+
+// Decode implements Decoder
+func (yamlDecoder) Decode(body []byte) (interface{}, error) {
+	data, err := yaml.YAMLToJSON(body)
+	if err != nil {
+		return nil, err
+	}
+	return JSON.Decode(data)
+}
+
+type formDecoder struct{}
+
+// Decode implements Decoder
+// Key with one value is decoded to a string, otherwise an array of strings
+func (formDecoder) Decode(body []byte) (interface{}, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	obj := map[string]interface{}{}
+	for k, vs := range values {
+		if len(vs) == 1 {
+			obj[k] = vs[0]
+			continue
+		}
+		arr := make([]interface{}, 0, len(vs))
+		for _, v := range vs {
+			arr = append(arr, v)
+		}
+		obj[k] = arr
+	}
+	return obj, nil
+}
+
+type textDecoder struct{}
+
+// Decode implements Decoder
+// Body which is a valid json object or array is decoded as json, because
+// servers often send json as text/plain, e.g. net/http does if Content-Type
+// is not set. Other body, e.g. 42 or true, is always a string
+func (textDecoder) Decode(body []byte) (interface{}, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return JSON.Decode(body)
+	}
+	return string(body), nil
+}
+
+const (
+	// XMLAttrPrefix defines prefix of key of xml attribute
+	XMLAttrPrefix = "@"
+	// XMLTextKey defines key of text of xml element which has attributes or children
+	XMLTextKey = "#text"
+)
+
+type xmlDecoder struct{}
+
+// Decode implements Decoder
+// Xml is decoded as below:
+//
+//	<a x="1"><b>2</b><b>3</b><c>4</c></a> => {"a": {"@x": "1", "b": ["2", "3"], "c": "4"}}
+//	<a x="1">text</a> => {"a": {"@x": "1", "#text": "text"}}
+//
+// Element which only has text is decoded to a string, repeated elements are
+// decoded to an array
+func (xmlDecoder) Decode(body []byte) (interface{}, error) {
+	d := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no root element in xml")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			v, err := decodeElement(d, start)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				start.Name.Local: v,
+			}, nil
+		}
+	}
+}
+
+func decodeElement(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	obj := map[string]interface{}{}
+	for _, attr := range start.Attr {
+		obj[XMLAttrPrefix+attr.Name.Local] = attr.Value
+	}
+	text := ""
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := decodeElement(d, t)
+			if err != nil {
+				return nil, err
+			}
+			// element is decoded to a string or an object,
+			// so an array means the element is repeated
+			name := t.Name.Local
+			switch existing := obj[name].(type) {
+			case nil:
+				obj[name] = child
+			case []interface{}:
+				obj[name] = append(existing, child)
+			default:
+				obj[name] = []interface{}{existing, child}
+			}
+		case xml.CharData:
+			text += string(t)
+		case xml.EndElement:
+			text = strings.TrimSpace(text)
+			if len(obj) == 0 {
+				return text, nil
+			}
+			if text != "" {
+				obj[XMLTextKey] = text
+			}
+			return obj, nil
+		}
+	}
+}
@@ -3,12 +3,41 @@ package config
 import (
 	"flag"
 	"fmt"
+	"time"
 )
 
 // Config defines config of test
 type Config struct {
 	Focus string
 	Skip  string
+
+	// Timeout defines default timeout of each request
+	// Zero means no timeout
+	Timeout time.Duration
+
+	// TLS defines tls config of default client
+	TLS TLS
+}
+
+// TLS defines tls config of default client
+type TLS struct {
+	// CA defines path of CA bundle in PEM
+	CA string
+	// Cert defines path of client certificate in PEM
+	Cert string
+	// Key defines path of client key in PEM
+	Key string
+	// ServerName overrides server name
+	ServerName string
+	// MinVersion defines min tls version
+	MinVersion string
+	// InsecureSkipVerify disables verification of server certificate
+	InsecureSkipVerify bool
+}
+
+// IsEmpty returns true if no tls config is set
+func (t *TLS) IsEmpty() bool {
+	return *t == TLS{}
 }
 
 func withPrefix(prefix, flagName string) string {
@@ -35,5 +64,40 @@ func (c *Config) ParseFlags(flagSet *flag.FlagSet, prefix string, defaults *Conf
 		withPrefix(prefix, "skip"),
 		defaults.Skip,
 		`skip cases with specified labels. Labels should be splited by comma. e.g. "aaa,bbb" means skip cases with "aaa" or "bbb" labels`)
+
+	flagSet.DurationVar(&c.Timeout,
+		withPrefix(prefix, "timeout"),
+		defaults.Timeout,
+		`default timeout of each request, e.g. "30s". Zero means no timeout`)
+
+	flagSet.StringVar(&c.TLS.CA,
+		withPrefix(prefix, "tls.ca"),
+		defaults.TLS.CA,
+		`path of CA bundle which verifies server certificates of default client`)
+
+	flagSet.StringVar(&c.TLS.Cert,
+		withPrefix(prefix, "tls.cert"),
+		defaults.TLS.Cert,
+		`path of client certificate of default client`)
+
+	flagSet.StringVar(&c.TLS.Key,
+		withPrefix(prefix, "tls.key"),
+		defaults.TLS.Key,
+		`path of client key of default client`)
+
+	flagSet.StringVar(&c.TLS.ServerName,
+		withPrefix(prefix, "tls.server-name"),
+		defaults.TLS.ServerName,
+		`server name which overrides host name for SNI and certificate verification`)
+
+	flagSet.StringVar(&c.TLS.MinVersion,
+		withPrefix(prefix, "tls.min-version"),
+		defaults.TLS.MinVersion,
+		`min tls version of default client, one of 1.0, 1.1, 1.2 and 1.3`)
+
+	flagSet.BoolVar(&c.TLS.InsecureSkipVerify,
+		withPrefix(prefix, "tls.insecure-skip-verify"),
+		defaults.TLS.InsecureSkipVerify,
+		`skip verification of server certificates`)
 	return nil
 }
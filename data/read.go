@@ -3,6 +3,7 @@ package data
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 
 	"github.com/ghodss/yaml"
@@ -57,6 +58,10 @@ func Walk(path string) (*Dir, error) {
 		name := file.Name()
 		childPath := filepath.Join(path, name)
 		if file.IsDir() {
+			if name == types.FixturesDir && !hasContext(childPath) {
+				// fixtures dir which has no context only stores body files
+				continue
+			}
 			childDir, err := Walk(childPath)
 			if err != nil {
 				return nil, err
@@ -78,6 +83,12 @@ func Walk(path string) (*Dir, error) {
 	return &dir, nil
 }
 
+// hasContext returns true if context file exists in dir
+func hasContext(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, types.ContextFile))
+	return err == nil
+}
+
 func readContext(dir string) (*types.Context, error) {
 	contextFile := filepath.Join(dir, types.ContextFile)
 
@@ -92,6 +103,10 @@ func readContext(dir string) (*types.Context, error) {
 	if err := ValidateContext(&context); err != nil {
 		return nil, err
 	}
+	if err := resolveFlow(context.Flow, dir); err != nil {
+		return nil, err
+	}
+	resolveClients(context.Clients, dir)
 	return &context, nil
 }
 
@@ -116,5 +131,76 @@ func readCase(file string) (*types.Case, error) {
 	if err := ValidateCase(&c); err != nil {
 		return nil, err
 	}
+	if err := resolveFlow(c.Flow, filepath.Dir(file)); err != nil {
+		return nil, err
+	}
 	return &c, nil
 }
+
+// resolveFlow resolves files referred by round trips
+// Relative paths are relative to dir of the file which defines round trips
+func resolveFlow(flow []types.RoundTrip, dir string) error {
+	for i := range flow {
+		req := &flow[i].Request
+		req.BaseDir = dir
+		if req.BodyFile != "" {
+			body, err := readTemplate(dir, req.BodyFile)
+			if err != nil {
+				return fmt.Errorf("flow[%v].request: %v", i, err)
+			}
+			req.Body = body
+		}
+		if req.BinaryBodyFile != "" {
+			body, err := ioutil.ReadFile(resolvePath(dir, req.BinaryBodyFile))
+			if err != nil {
+				return fmt.Errorf("flow[%v].request: can't read binary body file: %v", i, err)
+			}
+			req.RawBody = body
+		}
+		resp := &flow[i].Response
+		if resp.BodyFile != "" {
+			body, err := readTemplate(dir, resp.BodyFile)
+			if err != nil {
+				return fmt.Errorf("flow[%v].response: %v", i, err)
+			}
+			resp.Body = body
+		}
+	}
+	return nil
+}
+
+// resolveClients resolves paths of files in clients
+func resolveClients(clients map[string]types.Client, dir string) {
+	for name, c := range clients {
+		if c.TLS == nil {
+			continue
+		}
+		t := *c.TLS
+		for _, path := range []*string{&t.CA, &t.Cert, &t.Key} {
+			if *path != "" {
+				*path = resolvePath(dir, *path)
+			}
+		}
+		c.TLS = &t
+		clients[name] = c
+	}
+}
+
+func readTemplate(dir, file string) (*types.Template, error) {
+	body, err := ioutil.ReadFile(resolvePath(dir, file))
+	if err != nil {
+		return nil, fmt.Errorf("can't read body file: %v", err)
+	}
+	t, err := types.NewTemplate(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("can't parse body file %v: %v", file, err)
+	}
+	return t, nil
+}
+
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
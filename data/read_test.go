@@ -162,3 +162,145 @@ func TestWalk(t *testing.T) {
 		assert.Equal(t, c.expectedDir, dir, c.description)
 	}
 }
+
+func TestWalkWithBodyFiles(t *testing.T) {
+	path, err := ioutil.TempDir("", "test")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	files := map[string]string{
+		types.ContextFile: `summary: "test context"`,
+		filepath.Join(types.FixturesDir, "product.json"):  `{"title": "%{title}"}`,
+		filepath.Join(types.FixturesDir, "logo.png"):      "\x89PNG",
+		filepath.Join(types.FixturesDir, "expected.json"): `{"id": "1"}`,
+		"create.yaml": `
+summary: "create product"
+flow:
+- request:
+    api: POST /products
+    bodyFile: fixtures/product.json
+  response:
+    bodyFile: fixtures/expected.json
+- request:
+    api: PUT /products/1/logo
+    binaryBodyFile: fixtures/logo.png
+`,
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(path, types.FixturesDir), 0700))
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(path, name), []byte(content), 0666))
+	}
+
+	dir, err := Walk(path)
+	require.NoError(t, err)
+	assert.Empty(t, dir.Dirs)
+	require.Contains(t, dir.Files, "create.yaml")
+	flow := dir.Files["create.yaml"].Case.Flow
+	require.Len(t, flow, 2)
+
+	body, err := flow[0].Request.Body.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `{"title": "%{title}"}`, string(body))
+	body, err = flow[0].Response.Body.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `{"id": "1"}`, string(body))
+	assert.Equal(t, []byte("\x89PNG"), flow[1].Request.RawBody)
+	assert.Equal(t, path, flow[1].Request.BaseDir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(path, "missing.yaml"), []byte(`
+flow:
+- request:
+    bodyFile: fixtures/missing.json
+`), 0666))
+	_, err = Walk(path)
+	assert.Error(t, err)
+}
+
+func TestWalkFixturesContext(t *testing.T) {
+	path, err := ioutil.TempDir("", "test")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	// fixtures dir with a context is a context as usual
+	require.NoError(t, constructDir(path, &fakeDir{
+		name: "root",
+		dirs: []fakeDir{
+			{name: types.FixturesDir, files: []string{"a.yaml"}},
+		},
+	}))
+	dir, err := Walk(filepath.Join(path, "root"))
+	require.NoError(t, err)
+	require.Contains(t, dir.Dirs, types.FixturesDir)
+	assert.Equal(t, 1, dir.CaseNum)
+}
+
+func TestReadCaseTemplates(t *testing.T) {
+	path, err := ioutil.TempDir("", "test")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	cases := []struct {
+		description string
+		content     string
+		hasError    bool
+	}{
+		{
+			"header matchers and scalar query values",
+			`
+flow:
+- request:
+    api: GET /products
+    query:
+      page: 2
+      verbose: true
+  response:
+    headers:
+      Content-Type: application/json
+      X-Request-Id:
+        $regexp: "^[a-f0-9-]+$"
+`,
+			false,
+		},
+		{
+			"number template",
+			`
+flow:
+- request:
+    host: 8080
+`,
+			true,
+		},
+		{
+			"object template",
+			`
+flow:
+- request:
+    headers:
+      X-Meta:
+        a: b
+`,
+			true,
+		},
+		{
+			"header matcher without special matchers",
+			`
+flow:
+- response:
+    headers:
+      X-Meta:
+        a: b
+`,
+			true,
+		},
+	}
+	for _, c := range cases {
+		file := filepath.Join(path, "case.yaml")
+		require.NoError(t, ioutil.WriteFile(file, []byte(c.content), 0666), c.description)
+		_, err := readCase(file)
+		if c.hasError {
+			assert.Error(t, err, c.description)
+			continue
+		}
+		assert.NoError(t, err, c.description)
+	}
+}
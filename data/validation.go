@@ -24,6 +24,10 @@ func ValidateCase(c *types.Case) error {
 	if c == nil {
 		return fmt.Errorf("case is empty")
 	}
+	errList := validateFlow(c.Flow)
+	if len(errList) != 0 {
+		return errList
+	}
 	return nil
 }
 
@@ -32,9 +36,87 @@ func ValidateContext(c *types.Context) error {
 	if c == nil {
 		return fmt.Errorf("context is empty")
 	}
-	errList := ErrorList{}
+	errList := validateFlow(c.Flow)
+	for name, client := range c.Clients {
+		if name == "" {
+			errList = append(errList, fmt.Errorf("name of client is empty"))
+		}
+		if client.TLS != nil {
+			if err := validateTLS(client.TLS); err != nil {
+				errList = append(errList, fmt.Errorf("clients[%v].tls: %v", name, err))
+			}
+		}
+		switch client.CookieJar {
+		case "", "context", "case":
+		default:
+			errList = append(errList, fmt.Errorf("clients[%v]: unknown cookie jar scope %q: only [context, case] is allowed", name, client.CookieJar))
+		}
+	}
 	if len(errList) != 0 {
 		return errList
 	}
 	return nil
 }
+
+func validateFlow(flow []types.RoundTrip) ErrorList {
+	errList := ErrorList{}
+	for i := range flow {
+		if err := validateRequest(&flow[i].Request); err != nil {
+			errList = append(errList, fmt.Errorf("flow[%v].request: %v", i, err))
+		}
+		if flow[i].Response.Body != nil && flow[i].Response.BodyFile != "" {
+			errList = append(errList, fmt.Errorf("flow[%v].response: only one of body, bodyFile can be defined", i))
+		}
+	}
+	return errList
+}
+
+func validateRequest(req *types.Request) error {
+	bodies := []string{}
+	if req.Body != nil {
+		bodies = append(bodies, "body")
+	}
+	if req.BodyFile != "" {
+		bodies = append(bodies, "bodyFile")
+	}
+	if req.BinaryBodyFile != "" {
+		bodies = append(bodies, "binaryBodyFile")
+	}
+	if req.Form != nil {
+		bodies = append(bodies, "form")
+	}
+	if req.Multipart != nil {
+		bodies = append(bodies, "multipart")
+	}
+	if len(bodies) > 1 {
+		return fmt.Errorf("only one of %v can be defined", strings.Join(bodies, ", "))
+	}
+	switch req.Compress {
+	case "", "gzip", "deflate":
+	default:
+		return fmt.Errorf("unsupported compress %q: only [gzip, deflate] is allowed", req.Compress)
+	}
+	if req.Multipart != nil {
+		for i, f := range req.Multipart.Files {
+			if f.Name == "" {
+				return fmt.Errorf("name of multipart.files[%v] is empty", i)
+			}
+			if f.Path == nil {
+				return fmt.Errorf("path of multipart.files[%v] is empty", i)
+			}
+		}
+	}
+	return nil
+}
+
+func validateTLS(t *types.TLS) error {
+	if (t.Cert == "") != (t.Key == "") {
+		return fmt.Errorf("cert and key MUST be defined together")
+	}
+	switch t.MinVersion {
+	case "", "1.0", "1.1", "1.2", "1.3":
+	default:
+		return fmt.Errorf("unknown min version %q: only [1.0, 1.1, 1.2, 1.3] is allowed", t.MinVersion)
+	}
+	return nil
+}
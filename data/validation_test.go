@@ -56,6 +56,51 @@ func TestValidateCase(t *testing.T) {
 			c:           &types.Case{},
 			expected:    nil,
 		},
+		{
+			description: "body and form are both defined",
+			c: &types.Case{
+				Flow: []types.RoundTrip{
+					{
+						Request: types.Request{
+							Body: &types.Template{},
+							Form: types.Values{},
+						},
+					},
+				},
+			},
+			expected: ErrorList{fmt.Errorf("flow[0].request: only one of body, form can be defined")},
+		},
+		{
+			description: "unsupported compress",
+			c: &types.Case{
+				Flow: []types.RoundTrip{
+					{
+						Request: types.Request{
+							Compress: "br",
+						},
+					},
+				},
+			},
+			expected: ErrorList{fmt.Errorf(`flow[0].request: unsupported compress "br": only [gzip, deflate] is allowed`)},
+		},
+		{
+			description: "multipart file without path",
+			c: &types.Case{
+				Flow: []types.RoundTrip{
+					{},
+					{
+						Request: types.Request{
+							Multipart: &types.Multipart{
+								Files: []types.MultipartFile{
+									{Name: "image"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: ErrorList{fmt.Errorf("flow[1].request: path of multipart.files[0] is empty")},
+		},
 	}
 	for _, c := range cases {
 		assert.Equal(t, c.expected, ValidateCase(c.c), c.description)
@@ -73,6 +118,24 @@ func TestValidateContext(t *testing.T) {
 			c:           nil,
 			expected:    fmt.Errorf("context is empty"),
 		},
+		{
+			description: "client cert without key",
+			c: &types.Context{
+				Clients: map[string]types.Client{
+					"admin": {TLS: &types.TLS{Cert: "admin.pem"}},
+				},
+			},
+			expected: ErrorList{fmt.Errorf("clients[admin].tls: cert and key MUST be defined together")},
+		},
+		{
+			description: "unknown cookie jar scope",
+			c: &types.Context{
+				Clients: map[string]types.Client{
+					"admin": {CookieJar: "suite"},
+				},
+			},
+			expected: ErrorList{fmt.Errorf(`clients[admin]: unknown cookie jar scope "suite": only [context, case] is allowed`)},
+		},
 	}
 	for _, c := range cases {
 		assert.Equal(t, c.expected, ValidateContext(c.c), c.description)
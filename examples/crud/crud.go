@@ -2,14 +2,12 @@ package crud
 
 import (
 	"fmt"
-	"net"
-	"net/http"
 	"os"
 	"testing"
 
 	"github.com/caicloud/aloe"
 	"github.com/caicloud/aloe/examples/crud/server"
-	"github.com/onsi/ginkgo"
+	"github.com/emicklei/go-restful"
 )
 
 func init() {
@@ -21,7 +19,12 @@ var s = server.NewProductServer()
 // RunTEST runs crud test
 func RunTEST(t *testing.T) {
 	aloe.AppendDataDirs("testdata")
-	if err := aloe.Env("host", "localhost:8080"); err != nil {
+	s.Register()
+	if err := aloe.ServeHandler("products", restful.DefaultContainer); err != nil {
+		fmt.Printf("can't serve handler: %v", err)
+		os.Exit(1)
+	}
+	if err := aloe.Env("host", "products"); err != nil {
 		fmt.Printf("can't set env host: %v", err)
 		os.Exit(1)
 	}
@@ -31,13 +34,3 @@ func RunTEST(t *testing.T) {
 	}
 	aloe.Run(t)
 }
-
-var _ = ginkgo.BeforeSuite(func() {
-	s.Register()
-	listener, err := net.Listen("tcp", "localhost:8080")
-	if err != nil {
-		fmt.Println("can't begin server")
-		os.Exit(1)
-	}
-	go http.Serve(listener, nil)
-})
@@ -151,8 +151,13 @@ func (gf *genericFramework) onceRoundTrip(ctx *runtime.Context, originRoundTrip
 		}, timeout, interval).Should(respMatcher)
 	} else {
 		resp, err := gf.client.DoRequest(rt)
-		gomega.Expect(err).NotTo(gomega.HaveOccurred())
-		gomega.Expect(resp).To(respMatcher)
+		if !rt.Response.ExpectTimeout {
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		}
+		gomega.Expect(&roundtrip.Response{
+			Resp: resp,
+			Err:  err,
+		}).To(respMatcher)
 	}
 	return jsonutil.NewVariableMap("", respMatcher.Variables())
 }
@@ -5,10 +5,12 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/caicloud/aloe/cleaner"
 	"github.com/caicloud/aloe/config"
 	"github.com/caicloud/aloe/data"
+	"github.com/caicloud/aloe/matcher"
 	"github.com/caicloud/aloe/preset"
 	"github.com/caicloud/aloe/roundtrip"
 	"github.com/caicloud/aloe/runtime"
@@ -32,10 +34,21 @@ type Framework interface {
 	// RegisterPresetter registers presetter of framework
 	RegisterPresetter(ps ...preset.Presetter) error
 
+	// RegisterMatcher registers custom special matcher of body validator
+	RegisterMatcher(name string, f matcher.Factory) error
+
 	// CustomizeClient use custom client to replace default http client
 	// in framework
 	CustomizeClient(name string, c *http.Client)
 
+	// EnableCookieJar enables cookie jar of client with name
+	// Scope defines whether cookies are carried from contexts to cases
+	EnableCookieJar(name string, scope roundtrip.CookieScope) error
+
+	// ServeHandler serves requests to host with name by handler in process
+	// so that no port needs to be opened before running cases
+	ServeHandler(name string, h http.Handler) error
+
 	// Run will run the framework
 	Run(t *testing.T)
 }
@@ -45,6 +58,7 @@ func NewFramework(c *config.Config) Framework {
 	reqHeader := preset.NewHeaderPresetter(preset.RequestType)
 	respHeader := preset.NewHeaderPresetter(preset.ResponseType)
 	host := preset.NewHostPresetter()
+	query := preset.NewQueryPresetter()
 
 	gf := &genericFramework{
 		dataDirs: nil,
@@ -54,6 +68,7 @@ func NewFramework(c *config.Config) Framework {
 			reqHeader.Name():  reqHeader,
 			respHeader.Name(): respHeader,
 			host.Name():       host,
+			query.Name():      query,
 		},
 		adam: &runtime.Context{
 			Summary:   "adam context",
@@ -96,11 +111,21 @@ func (gf *genericFramework) Env(key, value string) error {
 }
 
 // CustomizeClient implements Framework interface
-// TODO(liubog2008): support multiple custom client config
+// Clients can also be defined in context files
 func (gf *genericFramework) CustomizeClient(name string, c *http.Client) {
 	gf.client.Set(name, c)
 }
 
+// EnableCookieJar implements Framework interface
+func (gf *genericFramework) EnableCookieJar(name string, scope roundtrip.CookieScope) error {
+	return gf.client.EnableCookieJar(name, scope)
+}
+
+// ServeHandler implements Framework interface
+func (gf *genericFramework) ServeHandler(name string, h http.Handler) error {
+	return gf.client.Serve(name, h)
+}
+
 // AppendDataDirs implements Framework interface
 func (gf *genericFramework) AppendDataDirs(ds ...string) {
 	gf.dataDirs = append(gf.dataDirs, ds...)
@@ -128,20 +153,62 @@ func (gf *genericFramework) RegisterPresetter(ps ...preset.Presetter) error {
 	return nil
 }
 
+// RegisterMatcher implements Framework interface
+func (gf *genericFramework) RegisterMatcher(name string, f matcher.Factory) error {
+	return matcher.Register(name, f)
+}
+
 func (gf *genericFramework) Run(t *testing.T) {
 	gomega.RegisterFailHandler(ginkgo.Fail)
+	defer gf.client.Close()
+	defer func() {
+		if err := roundtrip.RemoveSavedBodies(); err != nil {
+			t.Errorf("can't remove saved bodies: %v", err)
+		}
+	}()
 	if gf.c != nil {
 		gf.skip = arrayToSet(strings.Split(gf.c.Skip, ","))
 		gf.focus = arrayToSet(strings.Split(gf.c.Focus, ","))
+		if gf.c.Timeout != 0 {
+			// default timeout is inherited by all contexts
+			if gf.adam.RoundTripTemplate == nil {
+				gf.adam.RoundTripTemplate = &runtime.RoundTripTemplate{}
+			}
+			gf.adam.RoundTripTemplate.Request.Timeout = gf.c.Timeout
+		}
+		if !gf.c.TLS.IsEmpty() {
+			// tls flags are applied to default client customized in go
+			defaultClient, _ := gf.client.Get("")
+			c, err := roundtrip.WithTLS(defaultClient, &runtime.TLS{
+				CA:                 gf.c.TLS.CA,
+				Cert:               gf.c.TLS.Cert,
+				Key:                gf.c.TLS.Key,
+				ServerName:         gf.c.TLS.ServerName,
+				MinVersion:         gf.c.TLS.MinVersion,
+				InsecureSkipVerify: gf.c.TLS.InsecureSkipVerify,
+			})
+			if err != nil {
+				t.Fatal(err)
+				return
+			}
+			gf.client.Set("", c)
+		}
 	}
 	for _, r := range gf.dataDirs {
 		dir, err := data.Walk(r)
 		if err != nil {
-			t.Fatalf(err.Error())
+			t.Fatal(err)
 			return
 		}
 		f := gf.walk(gf.adam, dir)
-		ginkgo.Describe(dir.Context.Summary, f)
+		ginkgo.Describe(dir.Context.Summary, func() {
+			// cookies are reset before contexts are constructed for each case
+			ginkgo.BeforeEach(func() {
+				gf.client.ResetCookies(roundtrip.ContextScope)
+				gf.client.ResetCookies(roundtrip.CaseScope)
+			})
+			f()
+		})
 	}
 	ginkgo.RunSpecs(t, "Test Suit")
 }
@@ -192,6 +259,11 @@ func (gf *genericFramework) walk(parent *runtime.Context, dir *data.Dir) func()
 			gomega.Expect(gf.constructRoundTripTemplate(&ctx)).
 				NotTo(gomega.HaveOccurred())
 
+			gomega.Expect(runtime.RenderClients(&ctx, ctxConfig.Clients)).
+				NotTo(gomega.HaveOccurred())
+
+			runtime.ApplyStrict(&ctx, ctxConfig.Strict)
+
 			gf.constructFlow(&ctx, ctxConfig.Flow)
 
 			// render cleaner config
@@ -232,6 +304,14 @@ func (gf *genericFramework) itFunc(ctx *runtime.Context, file *data.File) func()
 			c.Summary,
 			ctx.Variables,
 		))
+		runtime.ApplyStrict(ctx, c.Strict)
+		gf.client.ResetCookies(roundtrip.CaseScope)
+		if c.Timeout != nil {
+			ctx.Deadline = time.Now().Add(c.Timeout.Duration)
+			defer func() {
+				ctx.Deadline = time.Time{}
+			}()
+		}
 		for _, rt := range c.Flow {
 			vs := gf.roundTrip(ctx, &rt)
 			newVs, err := jsonutil.Merge(ctx.Variables, jsonutil.ConflictOption, false, vs)
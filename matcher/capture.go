@@ -0,0 +1,172 @@
+package matcher
+
+import (
+	"fmt"
+
+	errorsutil "github.com/onsi/gomega/gstruct/errors"
+	"github.com/onsi/gomega/types"
+)
+
+// Capturer defines matcher which captures values while matching
+type Capturer interface {
+	// Captures returns values captured by the last match
+	// key is name of the capture
+	Captures() map[string]interface{}
+}
+
+// captureStore stores captured values of a parsed matcher
+type captureStore struct {
+	// names defines all declared captures
+	names map[string]struct{}
+
+	// values defines values captured by the last match
+	values map[string]interface{}
+}
+
+func newCaptureStore() *captureStore {
+	return &captureStore{
+		names:  map[string]struct{}{},
+		values: map[string]interface{}{},
+	}
+}
+
+func (cs *captureStore) declare(name string) error {
+	if _, ok := cs.names[name]; ok {
+		return fmt.Errorf("can't capture %v twice", name)
+	}
+	cs.names[name] = struct{}{}
+	return nil
+}
+
+// snapshot returns a copy of captured values
+func (cs *captureStore) snapshot() map[string]interface{} {
+	values := make(map[string]interface{}, len(cs.values))
+	for k, v := range cs.values {
+		values[k] = v
+	}
+	return values
+}
+
+// scopeCaptures wraps matchers of branches which may fail without failing
+// the whole matcher, so that values captured by failed branches are dropped
+// Nothing is wrapped if no capture is declared since declared number of captures
+func (p *parser) scopeCaptures(declared int, ms []types.GomegaMatcher) {
+	if len(p.captures.names) == declared {
+		return
+	}
+	for i, ma := range ms {
+		ms[i] = &captureScopeMatcher{
+			GomegaMatcher: ma,
+			store:         p.captures,
+		}
+	}
+}
+
+func (p *parser) generateCaptureMatcher(expr interface{}, inner types.GomegaMatcher) (types.GomegaMatcher, error) {
+	name, ok := expr.(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("value of $capture MUST be a non-empty string, actual: %v", expr)
+	}
+	if err := p.captures.declare(name); err != nil {
+		return nil, err
+	}
+	return &CapturingMatcher{
+		Name:    name,
+		Matcher: inner,
+		store:   p.captures,
+	}, nil
+}
+
+// CapturingMatcher records actual value if it is matched by the nested matcher
+type CapturingMatcher struct {
+	// Name defines name of captured value
+	Name string
+
+	// Matcher defines nested matcher
+	Matcher types.GomegaMatcher
+
+	store *captureStore
+}
+
+// Match implements types.GomegaMatcher
+func (m *CapturingMatcher) Match(actual interface{}) (bool, error) {
+	matched, err := m.Matcher.Match(actual)
+	if err != nil || !matched {
+		return matched, err
+	}
+	m.store.values[m.Name] = actual
+	return true, nil
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *CapturingMatcher) FailureMessage(actual interface{}) (message string) {
+	return m.Matcher.FailureMessage(actual)
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *CapturingMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return m.Matcher.NegatedFailureMessage(actual)
+}
+
+// Failures returns failures of nested matcher
+func (m *CapturingMatcher) Failures() []error {
+	if nesting, ok := m.Matcher.(errorsutil.NestingMatcher); ok {
+		return nesting.Failures()
+	}
+	return nil
+}
+
+// captureScopeMatcher drops values captured by nested matcher if it is not matched
+type captureScopeMatcher struct {
+	types.GomegaMatcher
+
+	store *captureStore
+
+	// actual is the value of the last match
+	actual interface{}
+}
+
+// Match implements types.GomegaMatcher
+func (m *captureScopeMatcher) Match(actual interface{}) (bool, error) {
+	m.actual = actual
+	saved := m.store.snapshot()
+	matched, err := m.GomegaMatcher.Match(actual)
+	if err != nil || !matched {
+		m.store.values = saved
+	}
+	return matched, err
+}
+
+// Failures returns failures of nested matcher
+func (m *captureScopeMatcher) Failures() []error {
+	if nesting, ok := m.GomegaMatcher.(errorsutil.NestingMatcher); ok {
+		return nesting.Failures()
+	}
+	return []error{failureOf(m.GomegaMatcher, m.actual)}
+}
+
+// captureRootMatcher is the root matcher of a parsed matcher with captures
+type captureRootMatcher struct {
+	types.GomegaMatcher
+
+	store *captureStore
+}
+
+// Match implements types.GomegaMatcher
+func (m *captureRootMatcher) Match(actual interface{}) (bool, error) {
+	m.store.values = map[string]interface{}{}
+	return m.GomegaMatcher.Match(actual)
+}
+
+// Failures returns failures of nested matcher
+func (m *captureRootMatcher) Failures() []error {
+	if nesting, ok := m.GomegaMatcher.(errorsutil.NestingMatcher); ok {
+		return nesting.Failures()
+	}
+	return nil
+}
+
+// Captures implements Capturer
+func (m *captureRootMatcher) Captures() map[string]interface{} {
+	return m.store.values
+}
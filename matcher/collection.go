@@ -0,0 +1,337 @@
+package matcher
+
+import (
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"strings"
+
+	"github.com/onsi/gomega/format"
+	errorsutil "github.com/onsi/gomega/gstruct/errors"
+	"github.com/onsi/gomega/types"
+)
+
+// MatchUnordered succeeds if elements of a slice can be matched by element matchers in any order.
+// If subset is true, elements which are not matched by any matcher are ignored.
+func MatchUnordered(elements Elements, subset bool) types.GomegaMatcher {
+	return &UnorderedElementsMatcher{
+		Elements: elements,
+		Subset:   subset,
+	}
+}
+
+// MatchUnorderedBy succeeds if every element matcher matches the element of a slice
+// which has the same value of key.
+// If subset is true, elements which are not matched by any matcher are ignored.
+func MatchUnorderedBy(key string, ids []interface{}, elements Elements, subset bool) types.GomegaMatcher {
+	return &UnorderedElementsMatcher{
+		Elements: elements,
+		Key:      key,
+		IDs:      ids,
+		Subset:   subset,
+	}
+}
+
+// UnorderedElementsMatcher is a NestingMatcher that matches elements of a slice in any order
+type UnorderedElementsMatcher struct {
+	// Matchers for each element.
+	Elements Elements
+
+	// Key defines the field name used to pair element matcher and element.
+	// If it is empty, element matchers and elements are paired by trying.
+	Key string
+
+	// IDs defines the value of key for each element matcher
+	IDs []interface{}
+
+	// Subset defines whether elements which are not matched are allowed
+	Subset bool
+
+	// State.
+	failures []error
+}
+
+// Match implements types.GomegaMatcher
+func (m *UnorderedElementsMatcher) Match(actual interface{}) (success bool, err error) {
+	if reflect.ValueOf(actual).Kind() != reflect.Slice {
+		return false, fmt.Errorf("%v is type %T, expected slice", actual, actual)
+	}
+
+	if m.Key != "" {
+		m.failures = m.matchElementsByKey(actual)
+	} else {
+		m.failures = m.matchElements(actual)
+	}
+	if len(m.failures) > 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (m *UnorderedElementsMatcher) matchElements(actual interface{}) (errs []error) {
+	// Provide more useful error messages in the case of a panic.
+	defer func() {
+		if err := recover(); err != nil {
+			errs = append(errs, fmt.Errorf("panic checking %+v: %v\n%s", actual, err, debug.Stack()))
+		}
+	}()
+
+	val := reflect.ValueOf(actual)
+	length := val.Len()
+	if !m.Subset && len(m.Elements) != length {
+		errs = append(errs, fmt.Errorf("unexpected slice length, expected: %v, actual: %v", len(m.Elements), length))
+		return errs
+	}
+
+	// matched[i][j] means element matcher i matches element j
+	matched := make([][]bool, len(m.Elements))
+	for i, matcher := range m.Elements {
+		matched[i] = make([]bool, length)
+		for j := 0; j < length; j++ {
+			ok, err := matcher.Match(val.Index(j).Interface())
+			matched[i][j] = ok && err == nil
+		}
+	}
+
+	pairs := maxBipartiteMatching(matched, length)
+	for i, j := range pairs {
+		if j == -1 {
+			errs = append(errs, fmt.Errorf("expected element [%v] matches none of the elements", i))
+			continue
+		}
+		// match again so that matcher keeps state of the chosen element
+		if _, err := m.Elements[i].Match(val.Index(j).Interface()); err != nil {
+			errs = append(errs, errorsutil.Nest(fmt.Sprintf("[%v]", j), err))
+		}
+	}
+	return errs
+}
+
+// maxBipartiteMatching pairs element matchers and elements
+// It returns index of the element paired with each element matcher, -1 means not paired
+func maxBipartiteMatching(matched [][]bool, length int) []int {
+	owners := make([]int, length)
+	for j := range owners {
+		owners[j] = -1
+	}
+
+	var augment func(i int, visited []bool) bool
+	augment = func(i int, visited []bool) bool {
+		for j := 0; j < length; j++ {
+			if !matched[i][j] || visited[j] {
+				continue
+			}
+			visited[j] = true
+			if owners[j] == -1 || augment(owners[j], visited) {
+				owners[j] = i
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := range matched {
+		augment(i, make([]bool, length))
+	}
+
+	pairs := make([]int, len(matched))
+	for i := range pairs {
+		pairs[i] = -1
+	}
+	for j, i := range owners {
+		if i != -1 {
+			pairs[i] = j
+		}
+	}
+	return pairs
+}
+
+func (m *UnorderedElementsMatcher) matchElementsByKey(actual interface{}) (errs []error) {
+	// Provide more useful error messages in the case of a panic.
+	defer func() {
+		if err := recover(); err != nil {
+			errs = append(errs, fmt.Errorf("panic checking %+v: %v\n%s", actual, err, debug.Stack()))
+		}
+	}()
+
+	val := reflect.ValueOf(actual)
+	length := val.Len()
+	claimed := make([]bool, length)
+	for i, matcher := range m.Elements {
+		id := m.IDs[i]
+		index := -1
+		for j := 0; j < length; j++ {
+			if claimed[j] {
+				continue
+			}
+			v, ok := keyOf(val.Index(j).Interface(), m.Key)
+			if ok && reflect.DeepEqual(v, id) {
+				index = j
+				break
+			}
+		}
+		if index == -1 {
+			errs = append(errs, fmt.Errorf("expected element [%v] with %v=%v is not found", i, m.Key, id))
+			continue
+		}
+		claimed[index] = true
+
+		element := val.Index(index).Interface()
+		if err := matchElement(matcher, element); err != nil {
+			errs = append(errs, errorsutil.Nest(fmt.Sprintf("[%v]", index), err))
+		}
+	}
+	if m.Subset {
+		return errs
+	}
+	for j := 0; j < length; j++ {
+		if claimed[j] {
+			continue
+		}
+		v, _ := keyOf(val.Index(j).Interface(), m.Key)
+		errs = append(errs, errorsutil.Nest(fmt.Sprintf("[%v]", j),
+			fmt.Errorf("unexpected element with %v=%v", m.Key, v)))
+	}
+	return errs
+}
+
+func keyOf(element interface{}, key string) (interface{}, bool) {
+	obj, ok := element.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	v, ok := obj[key]
+	return v, ok
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *UnorderedElementsMatcher) FailureMessage(actual interface{}) (message string) {
+	failure := errorsutil.AggregateError(m.failures)
+	return format.Message(actual, fmt.Sprintf("to match elements in any order: %v", failure))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *UnorderedElementsMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return format.Message(actual, "not to match elements in any order")
+}
+
+// Failures returns failures of matcher
+func (m *UnorderedElementsMatcher) Failures() []error {
+	return m.failures
+}
+
+// MatchEach succeeds if every element of a slice matches the element matcher
+func MatchEach(element types.GomegaMatcher) types.GomegaMatcher {
+	return &EachElementMatcher{
+		Element: element,
+	}
+}
+
+// EachElementMatcher is a NestingMatcher that applies one matcher to every element of a slice
+type EachElementMatcher struct {
+	// Element is matcher for every element
+	Element types.GomegaMatcher
+
+	// State.
+	failures []error
+}
+
+// Match implements types.GomegaMatcher
+func (m *EachElementMatcher) Match(actual interface{}) (success bool, err error) {
+	if reflect.ValueOf(actual).Kind() != reflect.Slice {
+		return false, fmt.Errorf("%v is type %T, expected slice", actual, actual)
+	}
+
+	m.failures = nil
+	val := reflect.ValueOf(actual)
+	for i := 0; i < val.Len(); i++ {
+		if err := matchElement(m.Element, val.Index(i).Interface()); err != nil {
+			m.failures = append(m.failures, errorsutil.Nest(fmt.Sprintf("[%v]", i), err))
+		}
+	}
+	if len(m.failures) > 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *EachElementMatcher) FailureMessage(actual interface{}) (message string) {
+	failure := errorsutil.AggregateError(m.failures)
+	return format.Message(actual, fmt.Sprintf("to match each element: %v", failure))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *EachElementMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return format.Message(actual, "not to match each element")
+}
+
+// Failures returns failures of matcher
+func (m *EachElementMatcher) Failures() []error {
+	return m.failures
+}
+
+// MatchAny succeeds if at least one element of a slice matches the element matcher
+func MatchAny(element types.GomegaMatcher) types.GomegaMatcher {
+	return &AnyElementMatcher{
+		Element: element,
+	}
+}
+
+// AnyElementMatcher applies one matcher to elements of a slice until one of them is matched
+type AnyElementMatcher struct {
+	// Element is matcher for any element
+	Element types.GomegaMatcher
+
+	// State.
+	failures []error
+}
+
+// Match implements types.GomegaMatcher
+func (m *AnyElementMatcher) Match(actual interface{}) (success bool, err error) {
+	if reflect.ValueOf(actual).Kind() != reflect.Slice {
+		return false, fmt.Errorf("%v is type %T, expected slice", actual, actual)
+	}
+
+	m.failures = nil
+	val := reflect.ValueOf(actual)
+	for i := 0; i < val.Len(); i++ {
+		err := matchElement(m.Element, val.Index(i).Interface())
+		if err == nil {
+			m.failures = nil
+			return true, nil
+		}
+		m.failures = append(m.failures, errorsutil.Nest(fmt.Sprintf("[%v]", i), err))
+	}
+	if val.Len() == 0 {
+		m.failures = append(m.failures, fmt.Errorf("no element in empty slice"))
+	}
+	return false, nil
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *AnyElementMatcher) FailureMessage(actual interface{}) (message string) {
+	failures := make([]string, len(m.failures))
+	for i := range m.failures {
+		failures[i] = m.failures[i].Error()
+	}
+	return format.Message(actual,
+		fmt.Sprintf("to have at least one matched element, but none matched: {\n%v\n}\n", strings.Join(failures, "\n")))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *AnyElementMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return format.Message(actual, "not to have any matched element")
+}
+
+// matchElement matches element and returns failure as error
+func matchElement(matcher types.GomegaMatcher, element interface{}) error {
+	match, err := matcher.Match(element)
+	if match {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return failureOf(matcher, element)
+}
@@ -0,0 +1,263 @@
+package matcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	errorsutil "github.com/onsi/gomega/gstruct/errors"
+	"github.com/onsi/gomega/matchers"
+	"github.com/onsi/gomega/types"
+)
+
+// maxMessageLength defines max length of message of a flattened failure
+const maxMessageLength = 256
+
+// MismatchError is the failure of a matcher which has no nested matchers
+type MismatchError struct {
+	// Message is the failure message of matcher
+	Message string
+
+	// Short is a one-line description of failure
+	Short string
+}
+
+// Error implements error
+func (e *MismatchError) Error() string {
+	return e.Message
+}
+
+// failureOf returns failure of matcher which doesn't match actual
+func failureOf(matcher types.GomegaMatcher, actual interface{}) error {
+	if nesting, ok := matcher.(errorsutil.NestingMatcher); ok {
+		return errorsutil.AggregateError(nesting.Failures())
+	}
+	return &MismatchError{
+		Message: matcher.FailureMessage(actual),
+		Short:   shortMessage(matcher, actual),
+	}
+}
+
+// shortMessage returns a one-line description of failure
+func shortMessage(matcher types.GomegaMatcher, actual interface{}) string {
+	switch m := matcher.(type) {
+	case *matchers.EqualMatcher:
+		return fmt.Sprintf("expected %v, got %v", toJSON(m.Expected), toJSON(actual))
+	case *matchers.BeNilMatcher:
+		return fmt.Sprintf("expected null, got %v", toJSON(actual))
+	case *matchers.BeNumericallyMatcher:
+		if m.Comparator == "==" && len(m.CompareTo) == 1 {
+			return fmt.Sprintf("expected %v, got %v", toJSON(m.CompareTo[0]), toJSON(actual))
+		}
+	}
+	return oneLine(matcher.FailureMessage(actual))
+}
+
+// toJSON formats value in json if it is possible
+func toJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return truncate(string(b), maxMessageLength)
+}
+
+// oneLine joins lines of message and truncates it
+func oneLine(message string) string {
+	return truncate(strings.Join(strings.Fields(message), " "), maxMessageLength)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "...(truncated)"
+}
+
+// Failure is a failure of field with full path
+type Failure struct {
+	// Path is full path of field, e.g. items[3].spec.replicas
+	// Empty path means the root
+	Path string
+
+	// Message describes why field is not matched
+	Message string
+}
+
+// String returns failure in format "path: message"
+func (f Failure) String() string {
+	if f.Path == "" {
+		return f.Message
+	}
+	return f.Path + ": " + f.Message
+}
+
+// Flatten returns failures of a matcher which doesn't match actual
+// Failures are sorted by path
+func Flatten(matcher types.GomegaMatcher, actual interface{}) []Failure {
+	failures := []Failure{}
+	flatten(failureOf(matcher, actual), "", &failures)
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].Path < failures[j].Path
+	})
+	return failures
+}
+
+func flatten(err error, path string, failures *[]Failure) {
+	switch e := err.(type) {
+	case errorsutil.AggregateError:
+		for _, sub := range e {
+			flatten(sub, path, failures)
+		}
+	case *errorsutil.NestedError:
+		flatten(e.Err, path+e.Path, failures)
+	case *MismatchError:
+		*failures = append(*failures, Failure{
+			Path:    formatPath(path),
+			Message: e.Short,
+		})
+	default:
+		*failures = append(*failures, Failure{
+			Path:    formatPath(path),
+			Message: oneLine(err.Error()),
+		})
+	}
+}
+
+// formatPath converts path of nested errors to full path of field
+// e.g. .items[3]$each[0].name => items[3][0].name
+// Keys of special matchers are removed because they are described by messages,
+// indexes of $and are removed too
+func formatPath(path string) string {
+	res := ""
+	dropIndex := false
+	for len(path) > 0 {
+		var seg string
+		switch path[0] {
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end == -1 {
+				end = len(path) - 1
+			}
+			seg, path = path[:end+1], path[end+1:]
+		case '(':
+			end := closingParen(path)
+			seg, path = path[:end+1], path[end+1:]
+		default:
+			end := strings.IndexAny(path[1:], ".[($")
+			if end == -1 {
+				end = len(path)
+			} else {
+				end++
+			}
+			seg, path = path[:end], path[end:]
+		}
+		switch {
+		case seg[0] == '$':
+			dropIndex = seg == AndMatcher
+			continue
+		case seg[0] == '[' && dropIndex:
+			dropIndex = false
+			continue
+		}
+		dropIndex = false
+		res += seg
+	}
+	return strings.TrimPrefix(res, ".")
+}
+
+// closingParen returns index of the parenthesis which closes the first one
+func closingParen(path string) int {
+	depth := 0
+	for i := range path {
+		switch path[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(path) - 1
+}
+
+// ExpectedView returns a view of expected json for comparing with actual
+// Fields which are matched by special matchers and have no failures are replaced by actual values,
+// fields which are not defined in expected are copied from actual unless there are failures of them.
+// So that the difference between view and actual only shows failures.
+func ExpectedView(expected, actual interface{}, failures []Failure) interface{} {
+	paths := make([]string, 0, len(failures))
+	for _, f := range failures {
+		paths = append(paths, f.Path)
+	}
+	return expectedView(expected, actual, "", paths)
+}
+
+func expectedView(expected, actual interface{}, path string, failures []string) interface{} {
+	if !hasFailure(path, failures) {
+		return actual
+	}
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		if checkSpMatcher(e) == specialMatcher {
+			return e
+		}
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return e
+		}
+		view := map[string]interface{}{}
+		for k, v := range e {
+			if StrictModifier == k || AllowModifier == k {
+				continue
+			}
+			child := joinPath(path, k)
+			av, ok := a[k]
+			if !ok {
+				view[k] = v
+				continue
+			}
+			view[k] = expectedView(v, av, child, failures)
+		}
+		for k, v := range a {
+			if _, ok := e[k]; ok {
+				continue
+			}
+			if !hasFailure(joinPath(path, k), failures) {
+				view[k] = v
+			}
+		}
+		return view
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			return e
+		}
+		view := make([]interface{}, len(e))
+		for i := range e {
+			view[i] = expectedView(e[i], a[i], fmt.Sprintf("%v[%v]", path, i), failures)
+		}
+		return view
+	}
+	return expected
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// hasFailure returns true if there are failures of path or its children
+func hasFailure(path string, failures []string) bool {
+	for _, f := range failures {
+		if path == "" || f == path || strings.HasPrefix(f, path+".") || strings.HasPrefix(f, path+"[") || strings.HasPrefix(f, path+"(") {
+			return true
+		}
+	}
+	return false
+}
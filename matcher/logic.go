@@ -0,0 +1,313 @@
+package matcher
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/caicloud/aloe/utils/jsonutil"
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	errorsutil "github.com/onsi/gomega/gstruct/errors"
+	"github.com/onsi/gomega/types"
+)
+
+// Existence of field is combined by logical matchers as below
+// (absent means the field doesn't exist, present means the field exists):
+//
+//   fieldUnkown, fieldExist: absent is not allowed, present value is matched
+//   fieldNotExist: absent is allowed, present value is never matched
+//   fieldOptional: absent is allowed, present value is matched
+//
+// $not only negates value if existence is fieldUnkown
+
+func absentAllowed(exist int) bool {
+	return exist == fieldNotExist || exist == fieldOptional
+}
+
+func presentNever(exist int) bool {
+	return exist == fieldNotExist
+}
+
+func existOf(absent, never bool, explicit bool) (int, error) {
+	switch {
+	case absent && never:
+		return fieldNotExist, nil
+	case absent:
+		return fieldOptional, nil
+	case never:
+		return fieldUnkown, fmt.Errorf("matcher can never be matched")
+	case explicit:
+		return fieldExist, nil
+	}
+	return fieldUnkown, nil
+}
+
+// andExist returns existence of field if it is required by both a and b
+func andExist(a, b int) (int, error) {
+	return existOf(
+		absentAllowed(a) && absentAllowed(b),
+		presentNever(a) || presentNever(b),
+		a == fieldExist || b == fieldExist,
+	)
+}
+
+// orExist returns existence of field if it is required by a or b
+func orExist(a, b int) (int, error) {
+	return existOf(
+		absentAllowed(a) || absentAllowed(b),
+		presentNever(a) && presentNever(b),
+		a == fieldExist || b == fieldExist,
+	)
+}
+
+// isAlways returns true if matcher matches everything
+func isAlways(m types.GomegaMatcher) bool {
+	sp, ok := m.(*SpecialMatcher)
+	return ok && len(sp.ms) == 0
+}
+
+func (p *parser) generateExprList(key string, expr interface{}) ([]types.GomegaMatcher, []int, error) {
+	s, ok := expr.([]interface{})
+	if !ok || len(s) == 0 {
+		return nil, nil, fmt.Errorf("value of %v MUST be a non-empty array, actual: %v", key, expr)
+	}
+	ms := make([]types.GomegaMatcher, 0, len(s))
+	exists := make([]int, 0, len(s))
+	for _, e := range s {
+		ma, exist, err := p.generateExprMatcher(e)
+		if err != nil {
+			return nil, nil, err
+		}
+		ms = append(ms, ma)
+		exists = append(exists, exist)
+	}
+	return ms, exists, nil
+}
+
+func (p *parser) generateNotMatcher(expr interface{}) (types.GomegaMatcher, int, error) {
+	declared := len(p.captures.names)
+	ma, exist, err := p.generateExprMatcher(expr)
+	if err != nil {
+		return nil, fieldUnkown, err
+	}
+	if len(p.captures.names) != declared {
+		// value matched by $not is never captured
+		return nil, fieldUnkown, fmt.Errorf("$capture can't be used in $not")
+	}
+	switch exist {
+	case fieldNotExist:
+		return MatchSpecial(nil), fieldExist, nil
+	case fieldOptional:
+		if isAlways(ma) {
+			return nil, fieldUnkown, fmt.Errorf("value of $not can always be matched")
+		}
+		return gomega.Not(ma), fieldExist, nil
+	}
+	if exist == fieldUnkown {
+		// existence is not defined in $not, only value is negated
+		return gomega.Not(ma), fieldUnkown, nil
+	}
+	if isAlways(ma) {
+		return MatchSpecial(nil), fieldNotExist, nil
+	}
+	return gomega.Not(ma), fieldOptional, nil
+}
+
+func (p *parser) generateOrMatcher(expr interface{}) (types.GomegaMatcher, int, error) {
+	declared := len(p.captures.names)
+	ms, exists, err := p.generateExprList(OrMatcher, expr)
+	if err != nil {
+		return nil, fieldUnkown, err
+	}
+	p.scopeCaptures(declared, ms)
+	exist := exists[0]
+	present := []types.GomegaMatcher{}
+	for i, ma := range ms {
+		if i > 0 {
+			exist, err = orExist(exist, exists[i])
+			if err != nil {
+				return nil, fieldUnkown, err
+			}
+		}
+		if !presentNever(exists[i]) {
+			present = append(present, ma)
+		}
+	}
+	return MatchAnyOf(present...), exist, nil
+}
+
+func (p *parser) generateAndMatcher(expr interface{}) (types.GomegaMatcher, int, error) {
+	ms, exists, err := p.generateExprList(AndMatcher, expr)
+	if err != nil {
+		return nil, fieldUnkown, err
+	}
+	exist := fieldOptional
+	all := map[string]types.GomegaMatcher{}
+	for i, ma := range ms {
+		exist, err = andExist(exist, exists[i])
+		if err != nil {
+			return nil, fieldUnkown, err
+		}
+		all[fmt.Sprintf("[%v]", i)] = ma
+	}
+	return MatchSpecial(all), exist, nil
+}
+
+func generateTypeMatcher(expr interface{}) (types.GomegaMatcher, error) {
+	s, ok := expr.(string)
+	if !ok {
+		return nil, fmt.Errorf("value of $type MUST be a string, actual: %T", expr)
+	}
+	t := jsonutil.JSONType(s)
+	switch t {
+	case jsonutil.StringType, jsonutil.NumberType, jsonutil.BooleanType,
+		jsonutil.ObjectType, jsonutil.ArrayType, jsonutil.NullType:
+	default:
+		return nil, fmt.Errorf("value of $type MUST be one of [string, number, boolean, object, array, null], actual: %v", s)
+	}
+	return MatchType(t), nil
+}
+
+func generateOneOfMatcher(expr interface{}) (types.GomegaMatcher, error) {
+	s, ok := expr.([]interface{})
+	if !ok || len(s) == 0 {
+		return nil, fmt.Errorf("value of $oneOf MUST be a non-empty array, actual: %v", expr)
+	}
+	for i, e := range s {
+		switch e.(type) {
+		case nil, string, float64, bool:
+		default:
+			return nil, fmt.Errorf("element [%v] of $oneOf MUST be a literal, actual: %T", i, e)
+		}
+	}
+	return MatchOneOf(s...), nil
+}
+
+// MatchAnyOf succeeds if actual matches at least one of matchers
+func MatchAnyOf(ms ...types.GomegaMatcher) types.GomegaMatcher {
+	return &AnyOfMatcher{
+		Matchers: ms,
+	}
+}
+
+// AnyOfMatcher is a matcher that tries matchers one by one until one of them is matched
+type AnyOfMatcher struct {
+	// Matchers defines candidates
+	Matchers []types.GomegaMatcher
+
+	// State.
+	failures []error
+}
+
+// Match implements types.GomegaMatcher
+func (m *AnyOfMatcher) Match(actual interface{}) (bool, error) {
+	m.failures = nil
+	for i, ma := range m.Matchers {
+		err := matchElement(ma, actual)
+		if err == nil {
+			m.failures = nil
+			return true, nil
+		}
+		m.failures = append(m.failures, errorsutil.Nest(fmt.Sprintf("[%v]", i), err))
+	}
+	return false, nil
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *AnyOfMatcher) FailureMessage(actual interface{}) (message string) {
+	failures := make([]string, len(m.failures))
+	for i := range m.failures {
+		failures[i] = m.failures[i].Error()
+	}
+	return format.Message(actual,
+		fmt.Sprintf("to match at least one of matchers, but none matched: {\n%v\n}\n", strings.Join(failures, "\n")))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *AnyOfMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return format.Message(actual, "not to match any of matchers")
+}
+
+// MatchType succeeds if actual is the json type
+func MatchType(t jsonutil.JSONType) types.GomegaMatcher {
+	return &JSONTypeMatcher{
+		Type: t,
+	}
+}
+
+// JSONTypeMatcher is a matcher to check json type of actual
+type JSONTypeMatcher struct {
+	// Type defines expected json type
+	Type jsonutil.JSONType
+}
+
+// Match implements types.GomegaMatcher
+func (m *JSONTypeMatcher) Match(actual interface{}) (bool, error) {
+	return typeOf(actual) == m.Type, nil
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *JSONTypeMatcher) FailureMessage(actual interface{}) (message string) {
+	return format.Message(actual, fmt.Sprintf("to be %v, actual type is %v", m.Type, typeOf(actual)))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *JSONTypeMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return format.Message(actual, fmt.Sprintf("not to be %v", m.Type))
+}
+
+// typeOf returns json type of value from json.Unmarshal
+func typeOf(actual interface{}) jsonutil.JSONType {
+	if actual == nil {
+		return jsonutil.NullType
+	}
+	switch reflect.TypeOf(actual).Kind() {
+	case reflect.String:
+		return jsonutil.StringType
+	case reflect.Bool:
+		return jsonutil.BooleanType
+	case reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return jsonutil.NumberType
+	case reflect.Map:
+		return jsonutil.ObjectType
+	case reflect.Slice, reflect.Array:
+		return jsonutil.ArrayType
+	}
+	return ""
+}
+
+// MatchOneOf succeeds if actual equals one of values
+func MatchOneOf(values ...interface{}) types.GomegaMatcher {
+	return &ElementOfMatcher{
+		Values: values,
+	}
+}
+
+// ElementOfMatcher is a matcher to check whether actual is one of values
+type ElementOfMatcher struct {
+	// Values defines candidates of actual
+	Values []interface{}
+}
+
+// Match implements types.GomegaMatcher
+func (m *ElementOfMatcher) Match(actual interface{}) (bool, error) {
+	for _, v := range m.Values {
+		if reflect.DeepEqual(v, actual) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *ElementOfMatcher) FailureMessage(actual interface{}) (message string) {
+	return format.Message(actual, "to be one of", m.Values)
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *ElementOfMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return format.Message(actual, "not to be one of", m.Values)
+}
@@ -1,7 +1,6 @@
 package matcher
 
 import (
-	"errors"
 	"fmt"
 	"reflect"
 	"runtime/debug"
@@ -17,6 +16,11 @@ import (
 
 // MatchMap succeeds if every field of a struct matches the field matcher associated with it, and every element matcher is matched.
 func MatchMap(fields Fields, exists map[string]bool) types.GomegaMatcher {
+	return matchMap(fields, exists, nil)
+}
+
+// matchMap returns a FieldsMatcher, fields in optional will not be checked for existence
+func matchMap(fields Fields, exists map[string]bool, optional map[string]bool) *FieldsMatcher {
 	m := &FieldsMatcher{
 		Fields: fields,
 		Exists: map[string]bool{},
@@ -25,6 +29,9 @@ func MatchMap(fields Fields, exists map[string]bool) types.GomegaMatcher {
 		m.Exists[k] = v
 	}
 	for name := range fields {
+		if optional[name] {
+			continue
+		}
 		if _, ok := exists[name]; !ok {
 			m.Exists[name] = true
 		}
@@ -42,6 +49,14 @@ type FieldsMatcher struct {
 	// checked for existence
 	Exists map[string]bool
 
+	// Strict defines whether fields which are not in Fields are rejected
+	Strict bool
+
+	// Allowed defines fields which are allowed in strict mode
+	// even if they are not in Fields
+	// "*" means all fields are allowed
+	Allowed map[string]bool
+
 	// State.
 	failures []error
 }
@@ -87,6 +102,9 @@ func (m *FieldsMatcher) matchFields(actual interface{}) (errs []error) {
 
 			matcher, expected := m.Fields[fieldName]
 			if !expected {
+				if m.Strict && !m.Allowed[fieldName] && !m.Allowed["*"] {
+					return fmt.Errorf("unexpected field in strict mode")
+				}
 				return nil
 			}
 
@@ -103,10 +121,7 @@ func (m *FieldsMatcher) matchFields(actual interface{}) (errs []error) {
 			if err != nil {
 				return err
 			} else if !matched {
-				if nesting, ok := matcher.(errorsutil.NestingMatcher); ok {
-					return errorsutil.AggregateError(nesting.Failures())
-				}
-				return errors.New(matcher.FailureMessage(field))
+				return failureOf(matcher, field)
 			}
 			return nil
 		}()
@@ -4,43 +4,127 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/onsi/gomega"
 	gomegatypes "github.com/onsi/gomega/types"
 )
 
-func generateMapMatcher(matcher map[string]interface{}) (gomegatypes.GomegaMatcher, error) {
-	fields := Fields{}
-	exists := map[string]bool{}
-	for k, expr := range matcher {
-		if expr == nil {
-			fields[k] = gomega.BeNil()
-			continue
+// Options defines options of parsing matcher
+type Options struct {
+	// Strict defines whether fields of object which are not
+	// defined in matcher will be rejected
+	Strict bool
+
+	// AllowedFields defines paths of fields which are allowed
+	// in strict mode even if they are not defined in matcher
+	// Keys in path are split by ".", "*" matches any key or index of array
+	// e.g. "metadata.resourceVersion", "items.*.updatedAt", "items.0.updatedAt"
+	AllowedFields []string
+}
+
+// parser defines state of parsing
+type parser struct {
+	// strict defines whether strict mode is enabled for current object
+	strict bool
+
+	// allowed defines paths of allowed fields relative to current object
+	allowed [][]string
+
+	// captures stores values captured by $capture
+	captures *captureStore
+}
+
+func newParser(opts *Options) *parser {
+	p := &parser{
+		captures: newCaptureStore(),
+	}
+	if opts == nil {
+		return p
+	}
+	p.strict = opts.Strict
+	p.allowed = splitPaths(opts.AllowedFields)
+	return p
+}
+
+func splitPaths(paths []string) [][]string {
+	res := make([][]string, 0, len(paths))
+	for _, path := range paths {
+		res = append(res, strings.Split(path, "."))
+	}
+	return res
+}
+
+// nested returns parser for field with key in current object
+func (p *parser) nested(key string) *parser {
+	np := &parser{
+		strict:   p.strict,
+		captures: p.captures,
+	}
+	for _, path := range p.allowed {
+		if len(path) > 1 && (path[0] == key || path[0] == "*") {
+			np.allowed = append(np.allowed, path[1:])
 		}
-		m, ok := convertToMap(expr)
-		if ok {
-			matcher, exist, err := generateSpMatcher(m)
-			if err != nil {
-				return nil, err
-			}
-			switch exist {
-			case fieldNotExist:
-				exists[k] = false
-			case fieldExist:
-				exists[k] = true
-			}
-			fields[k] = matcher
-			continue
+	}
+	return np
+}
+
+// element returns parser for element with index in current array
+// index is negative if element may match any element, e.g. in $each
+func (p *parser) element(index int) *parser {
+	if index < 0 {
+		return p.nested("*")
+	}
+	return p.nested(strconv.Itoa(index))
+}
+
+// allowedKeys returns keys allowed in current object
+func (p *parser) allowedKeys() map[string]bool {
+	keys := map[string]bool{}
+	for _, path := range p.allowed {
+		if len(path) == 1 {
+			keys[path[0]] = true
 		}
+	}
+	return keys
+}
 
-		matcher, err := generateMatcher(expr)
+func (p *parser) generateMapMatcher(matcher map[string]interface{}) (gomegatypes.GomegaMatcher, error) {
+	fields := Fields{}
+	exists := map[string]bool{}
+	optional := map[string]bool{}
+	for k, expr := range matcher {
+		matcher, exist, err := p.nested(k).generateExprMatcher(expr)
 		if err != nil {
 			return nil, err
 		}
+		switch exist {
+		case fieldNotExist:
+			exists[k] = false
+		case fieldExist:
+			exists[k] = true
+		case fieldOptional:
+			optional[k] = true
+		}
 		fields[k] = matcher
 	}
-	return MatchMap(fields, exists), nil
+	m := matchMap(fields, exists, optional)
+	if p.strict {
+		m.Strict = true
+		m.Allowed = p.allowedKeys()
+	}
+	return m, nil
+}
+
+// generateExprMatcher returns matcher and existence of field from expr
+func (p *parser) generateExprMatcher(expr interface{}) (gomegatypes.GomegaMatcher, int, error) {
+	m, ok := convertToMap(expr)
+	if ok {
+		return p.generateSpMatcher(m)
+	}
+	matcher, err := p.generateMatcher(expr)
+	return matcher, fieldUnkown, err
 }
 
 const (
@@ -86,23 +170,78 @@ const (
 	fieldUnkown   = -1
 	fieldNotExist = 0
 	fieldExist    = 1
+	// fieldOptional means field is only matched if it exists
+	fieldOptional = 2
 )
 
-func generateSpMatcher(m map[string]interface{}) (gomegatypes.GomegaMatcher, int, error) {
+// withModifiers extracts modifiers of object and returns parser with them
+func (p *parser) withModifiers(m map[string]interface{}) (*parser, map[string]interface{}, error) {
+	strict, hasStrict := m[StrictModifier]
+	allow, hasAllow := m[AllowModifier]
+	if !hasStrict && !hasAllow {
+		return p, m, nil
+	}
+	np := &parser{
+		strict:   p.strict,
+		allowed:  p.allowed,
+		captures: p.captures,
+	}
+	if hasStrict {
+		b, ok := strict.(bool)
+		if !ok {
+			return nil, nil, fmt.Errorf("value of $strict MUST be bool, actual: %T", strict)
+		}
+		np.strict = b
+	}
+	if hasAllow {
+		s, ok := allow.([]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("value of $allow MUST be an array of string, actual: %T", allow)
+		}
+		paths := make([]string, 0, len(s))
+		for _, e := range s {
+			path, ok := e.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("value of $allow MUST be an array of string, actual element: %T", e)
+			}
+			paths = append(paths, path)
+		}
+		np.allowed = append(splitPaths(paths), p.allowed...)
+	}
+
+	nm := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if k != StrictModifier && k != AllowModifier {
+			nm[k] = v
+		}
+	}
+	return np, nm, nil
+}
+
+func (p *parser) generateSpMatcher(m map[string]interface{}) (gomegatypes.GomegaMatcher, int, error) {
+	p, m, err := p.withModifiers(m)
+	if err != nil {
+		return nil, fieldUnkown, err
+	}
 	res := checkSpMatcher(m)
 	if res == mixedMatcher {
 		return nil, fieldUnkown, fmt.Errorf("mixed special matcher and fields")
 	}
 	if res == normalMatcher {
-		m, err := generateMapMatcher(m)
+		m, err := p.generateMapMatcher(m)
 		return m, fieldUnkown, err
 	}
 	ms := map[string]gomegatypes.GomegaMatcher{}
-	exist := fieldUnkown
+	// existences defines existence required by every special matcher
+	existences := []int{}
 	for key, expr := range m {
+		if key != ExistsMatcher && key != UnorderedByMatcher && key != NotMatcher &&
+			key != OrMatcher && key != AndMatcher {
+			existences = append(existences, fieldUnkown)
+		}
 		switch key {
 		case MatchMatcher:
-			ma, err := generateMatcher(expr)
+			ma, err := p.generateMatcher(expr)
 			if err != nil {
 				return nil, fieldUnkown, err
 			}
@@ -120,6 +259,84 @@ func generateSpMatcher(m map[string]interface{}) (gomegatypes.GomegaMatcher, int
 				return nil, fieldUnkown, err
 			}
 			ms[RegexpMatcher] = ma
+		case ContainsMatcher, UnorderedMatcher:
+			ma, err := p.generateUnorderedMatcher(key, expr, m[UnorderedByMatcher])
+			if err != nil {
+				return nil, fieldUnkown, err
+			}
+			ms[key] = ma
+		case UnorderedByMatcher:
+			_, hasContains := m[ContainsMatcher]
+			_, hasUnordered := m[UnorderedMatcher]
+			if !hasContains && !hasUnordered {
+				return nil, fieldUnkown, fmt.Errorf("$unorderedBy MUST be used with $contains or $unordered")
+			}
+		case EachMatcher:
+			ma, err := p.element(-1).generateMatcher(expr)
+			if err != nil {
+				return nil, fieldUnkown, err
+			}
+			ms[EachMatcher] = MatchEach(ma)
+		case AnyMatcher:
+			declared := len(p.captures.names)
+			ma, err := p.element(-1).generateMatcher(expr)
+			if err != nil {
+				return nil, fieldUnkown, err
+			}
+			elems := []gomegatypes.GomegaMatcher{ma}
+			p.scopeCaptures(declared, elems)
+			ms[AnyMatcher] = MatchAny(elems[0])
+		case TypeMatcher:
+			ma, err := generateTypeMatcher(expr)
+			if err != nil {
+				return nil, fieldUnkown, err
+			}
+			ms[TypeMatcher] = ma
+		case OneOfMatcher:
+			ma, err := generateOneOfMatcher(expr)
+			if err != nil {
+				return nil, fieldUnkown, err
+			}
+			ms[OneOfMatcher] = ma
+		case PathMatcher:
+			ma, err := p.generatePathMatcher(expr)
+			if err != nil {
+				return nil, fieldUnkown, err
+			}
+			ms[PathMatcher] = ma
+		case CaptureMatcher:
+			// value will be captured after all other matchers are matched
+		case TimeMatcher:
+			ma, err := generateTimeMatcher(expr)
+			if err != nil {
+				return nil, fieldUnkown, err
+			}
+			ms[TimeMatcher] = ma
+		case TimeFormatMatcher:
+			ma, err := generateTimeFormatMatcher(expr)
+			if err != nil {
+				return nil, fieldUnkown, err
+			}
+			ms[TimeFormatMatcher] = ma
+		case NotMatcher, OrMatcher, AndMatcher:
+			var ma gomegatypes.GomegaMatcher
+			var e int
+			var err error
+			switch key {
+			case NotMatcher:
+				ma, e, err = p.generateNotMatcher(expr)
+			case OrMatcher:
+				ma, e, err = p.generateOrMatcher(expr)
+			case AndMatcher:
+				ma, e, err = p.generateAndMatcher(expr)
+			}
+			if err != nil {
+				return nil, fieldUnkown, err
+			}
+			existences = append(existences, e)
+			if !presentNever(e) {
+				ms[key] = ma
+			}
 		case ExistsMatcher:
 			b, ok := expr.(bool)
 			if !ok {
@@ -130,15 +347,38 @@ func generateSpMatcher(m map[string]interface{}) (gomegatypes.GomegaMatcher, int
 				if len(m) != 1 {
 					return nil, fieldUnkown, fmt.Errorf("if $exists is false, all other matchers will be ignored")
 				}
-				exist = fieldNotExist
+				existences = append(existences, fieldNotExist)
 			} else {
-				exist = fieldExist
+				existences = append(existences, fieldExist)
 			}
 		default:
-			return nil, fieldUnkown, fmt.Errorf("unknown special matcher: %v", key)
+			ma, err := generateCustomMatcher(key, expr)
+			if err != nil {
+				return nil, fieldUnkown, err
+			}
+			ms[key] = ma
 		}
 	}
-	return MatchSpecial(ms), exist, nil
+	exist := fieldOptional
+	for _, e := range existences {
+		var err error
+		exist, err = andExist(exist, e)
+		if err != nil {
+			return nil, fieldUnkown, err
+		}
+	}
+	name, ok := m[CaptureMatcher]
+	if !ok {
+		return MatchSpecial(ms), exist, nil
+	}
+	if exist == fieldNotExist {
+		return nil, fieldUnkown, fmt.Errorf("can't capture field which doesn't exist")
+	}
+	ma, err := p.generateCaptureMatcher(name, MatchSpecial(ms))
+	if err != nil {
+		return nil, fieldUnkown, err
+	}
+	return ma, exist, nil
 }
 
 func generateLenMatcher(expr interface{}) (gomegatypes.GomegaMatcher, error) {
@@ -161,6 +401,52 @@ func generateRegexpMatcher(expr interface{}) (gomegatypes.GomegaMatcher, error)
 	return gomega.MatchRegexp(s), nil
 }
 
+func (p *parser) generateUnorderedMatcher(key string, expr interface{}, by interface{}) (gomegatypes.GomegaMatcher, error) {
+	s, ok := expr.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("value of %v MUST be an array, actual: %T", key, expr)
+	}
+	subset := key == ContainsMatcher
+	declared := len(p.captures.names)
+	elems := Elements{}
+	for _, e := range s {
+		elem, err := p.element(-1).generateMatcher(e)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, elem)
+	}
+	// values captured by elements which are not paired are dropped,
+	// and paired elements are matched again after pairing
+	p.scopeCaptures(declared, elems)
+	if by == nil {
+		return MatchUnordered(elems, subset), nil
+	}
+
+	idKey, ok := by.(string)
+	if !ok || idKey == "" {
+		return nil, fmt.Errorf("value of $unorderedBy MUST be a non-empty string, actual: %v", by)
+	}
+	ids := make([]interface{}, 0, len(s))
+	for i, e := range s {
+		obj, ok := convertToMap(e)
+		if !ok {
+			return nil, fmt.Errorf("element [%v] of %v MUST be an object if $unorderedBy is set, actual: %T", i, key, e)
+		}
+		id, ok := obj[idKey]
+		if !ok {
+			return nil, fmt.Errorf("element [%v] of %v MUST have key %v", i, key, idKey)
+		}
+		switch id.(type) {
+		case string, float64, bool:
+		default:
+			return nil, fmt.Errorf("value of key %v in element [%v] MUST be a string, number or bool, actual: %T", idKey, i, id)
+		}
+		ids = append(ids, id)
+	}
+	return MatchUnorderedBy(idKey, ids, elems, subset), nil
+}
+
 func convertToMap(expr interface{}) (map[string]interface{}, bool) {
 	m, ok := expr.(map[string]interface{})
 	if !ok {
@@ -226,12 +512,287 @@ const (
 	//   "string": "1234"
 	// }
 	LenMatcher = "$len"
+
+	// ContainsMatcher defines matcher matches a subset of array in any order
+	// e.g.
+	// matcher:
+	// {
+	//   "array": {
+	//     "$contains": ["ccc", "aaa"]
+	//   }
+	// }
+	// data:
+	// {
+	//   "array": ["aaa", "bbb", "ccc"]
+	// }
+	ContainsMatcher = "$contains"
+
+	// UnorderedMatcher defines matcher matches all elements of array in any order
+	// e.g.
+	// matcher:
+	// {
+	//   "array": {
+	//     "$unordered": ["ccc", "bbb", "aaa"]
+	//   }
+	// }
+	// data:
+	// {
+	//   "array": ["aaa", "bbb", "ccc"]
+	// }
+	UnorderedMatcher = "$unordered"
+
+	// UnorderedByMatcher defines key used to pair elements of $contains or $unordered
+	// All elements of matcher MUST be objects with the key
+	// e.g.
+	// matcher:
+	// {
+	//   "array": {
+	//     "$unorderedBy": "id",
+	//     "$unordered": [
+	//       {"id": "2", "title": "bbb"},
+	//       {"id": "1", "title": "aaa"}
+	//     ]
+	//   }
+	// }
+	// data:
+	// {
+	//   "array": [
+	//     {"id": "1", "title": "aaa"},
+	//     {"id": "2", "title": "bbb"}
+	//   ]
+	// }
+	UnorderedByMatcher = "$unorderedBy"
+
+	// EachMatcher defines matcher matches every element of array
+	// e.g.
+	// matcher:
+	// {
+	//   "array": {
+	//     "$each": {
+	//       "$regexp": "^[a-z]+$"
+	//     }
+	//   }
+	// }
+	// data:
+	// {
+	//   "array": ["aaa", "bbb"]
+	// }
+	EachMatcher = "$each"
+
+	// AnyMatcher defines matcher matches at least one element of array
+	// e.g.
+	// matcher:
+	// {
+	//   "array": {
+	//     "$any": {
+	//       "id": "2"
+	//     }
+	//   }
+	// }
+	// data:
+	// {
+	//   "array": [
+	//     {"id": "1"},
+	//     {"id": "2"}
+	//   ]
+	// }
+	AnyMatcher = "$any"
+
+	// TypeMatcher defines matcher matches json type of data
+	// enum ["string", "number", "boolean", "object", "array", "null"]
+	// e.g.
+	// matcher:
+	// {
+	//   "obj": {
+	//     "$type": "object"
+	//   }
+	// }
+	// data:
+	// {
+	//   "obj": {}
+	// }
+	TypeMatcher = "$type"
+
+	// OneOfMatcher defines matcher matches one of literals
+	// e.g.
+	// matcher:
+	// {
+	//   "status": {
+	//     "$oneOf": ["Running", "Pending"]
+	//   }
+	// }
+	// data:
+	// {
+	//   "status": "Running"
+	// }
+	OneOfMatcher = "$oneOf"
+
+	// NotMatcher defines matcher matches data which is not matched by nested matcher
+	// e.g.
+	// matcher:
+	// {
+	//   "status": {
+	//     "$not": "Failed"
+	//   }
+	// }
+	// data:
+	// {
+	//   "status": "Running"
+	// }
+	NotMatcher = "$not"
+
+	// OrMatcher defines matcher matches data which is matched by any of nested matchers
+	// e.g.
+	// matcher:
+	// {
+	//   "string": {
+	//     "$or": [
+	//       null,
+	//       {"$type": "string"}
+	//     ]
+	//   }
+	// }
+	// data:
+	// {
+	//   "string": null
+	// }
+	OrMatcher = "$or"
+
+	// AndMatcher defines matcher matches data which is matched by all nested matchers
+	// e.g.
+	// matcher:
+	// {
+	//   "obj": {
+	//     "$and": [
+	//       {"$type": "object"},
+	//       {"$not": {"$len": 0}}
+	//     ]
+	//   }
+	// }
+	// data:
+	// {
+	//   "obj": {"aaa": "aaa"}
+	// }
+	AndMatcher = "$and"
+
+	// TimeMatcher defines matcher matches time
+	// format defines format of time, it can be a name of layout
+	// (e.g. RFC3339, RFC1123, unix, unixMilli) or a golang layout,
+	// default is RFC3339
+	// within defines max duration between time and now
+	// after and before defines range of time
+	// e.g.
+	// matcher:
+	// {
+	//   "createdAt": {
+	//     "$time": {
+	//       "format": "RFC3339",
+	//       "within": "1m",
+	//       "after": "2018-01-01T00:00:00Z"
+	//     }
+	//   }
+	// }
+	// data:
+	// {
+	//   "createdAt": "2018-01-01T00:00:01Z"
+	// }
+	TimeMatcher = "$time"
+
+	// TimeFormatMatcher defines matcher matches time in format
+	// e.g.
+	// matcher:
+	// {
+	//   "createdAt": {
+	//     "$timeFormat": "RFC3339"
+	//   }
+	// }
+	// data:
+	// {
+	//   "createdAt": "2018-01-01T00:00:00Z"
+	// }
+	TimeFormatMatcher = "$timeFormat"
+
+	// CaptureMatcher defines name of variable which captures matched data
+	// Data is only captured if all other special matchers are matched
+	// Matcher returned by Parse implements Capturer if it has captures
+	// e.g.
+	// matcher:
+	// {
+	//   "id": {
+	//     "$regexp": "^p-",
+	//     "$capture": "productId"
+	//   }
+	// }
+	// data:
+	// {
+	//   "id": "p-123"
+	// }
+	// will capture "p-123" as productId
+	CaptureMatcher = "$capture"
+
+	// PathMatcher defines matchers of values selected by jsonpath
+	// If jsonpath is not definite, an array of all selected values is matched
+	// e.g.
+	// matcher:
+	// {
+	//   "$path": {
+	//     "$.items[*].id": {"$contains": ["1"]},
+	//     "$.items[?(@.name == 'aaa')].id": ["2"]
+	//   }
+	// }
+	// data:
+	// {
+	//   "items": [{"id": "1", "name": "bbb"}, {"id": "2", "name": "aaa"}]
+	// }
+	PathMatcher = "$path"
 )
 
-func generateSliceMatcher(matcher []interface{}) (gomegatypes.GomegaMatcher, error) {
+const (
+	// StrictModifier defines whether fields which are not defined in
+	// object matcher are rejected
+	// It is inherited by nested objects
+	// e.g.
+	// matcher:
+	// {
+	//   "$strict": true,
+	//   "id": "1"
+	// }
+	// data:
+	// {
+	//   "id": "1",
+	//   "password": "password"
+	// }
+	// will fail because of unexpected field password
+	StrictModifier = "$strict"
+
+	// AllowModifier defines paths of fields which are allowed in strict mode
+	// Keys in path are split by ".", "*" matches any key
+	// e.g.
+	// matcher:
+	// {
+	//   "$strict": true,
+	//   "$allow": ["updatedAt", "metadata.resourceVersion"],
+	//   "id": "1",
+	//   "metadata": {
+	//     "name": "aaa"
+	//   }
+	// }
+	// data:
+	// {
+	//   "id": "1",
+	//   "updatedAt": "2018-01-01T00:00:00Z",
+	//   "metadata": {
+	//     "name": "aaa",
+	//     "resourceVersion": "1"
+	//   }
+	// }
+	AllowModifier = "$allow"
+)
+
+func (p *parser) generateSliceMatcher(matcher []interface{}) (gomegatypes.GomegaMatcher, error) {
 	elems := Elements{}
-	for _, expr := range matcher {
-		elem, err := generateMatcher(expr)
+	for i, expr := range matcher {
+		elem, err := p.element(i).generateMatcher(expr)
 		if err != nil {
 			return nil, err
 		}
@@ -240,7 +801,10 @@ func generateSliceMatcher(matcher []interface{}) (gomegatypes.GomegaMatcher, err
 	return MatchSlice(elems), nil
 }
 
-func generateMatcher(expr interface{}) (gomegatypes.GomegaMatcher, error) {
+func (p *parser) generateMatcher(expr interface{}) (gomegatypes.GomegaMatcher, error) {
+	if expr == nil {
+		return gomega.BeNil(), nil
+	}
 	t := reflect.TypeOf(expr)
 	switch t.Kind() {
 	case reflect.String, reflect.Bool:
@@ -252,14 +816,14 @@ func generateMatcher(expr interface{}) (gomegatypes.GomegaMatcher, error) {
 		if !ok {
 			return nil, fmt.Errorf("expr type %T is a slice(array) but can't be []interface{}", expr)
 		}
-		return generateSliceMatcher(s)
+		return p.generateSliceMatcher(s)
 	case reflect.Map:
 		m, ok := expr.(map[string]interface{})
 		if !ok {
 			return nil, fmt.Errorf("expr type %T is a map but can't be map[string]interface{}", expr)
 		}
 
-		ma, _, err := generateSpMatcher(m)
+		ma, _, err := p.generateSpMatcher(m)
 		if err != nil {
 			return nil, err
 		}
@@ -271,9 +835,25 @@ func generateMatcher(expr interface{}) (gomegatypes.GomegaMatcher, error) {
 
 // Parse parse matcher of response and returns GomegaMatcher
 func Parse(matcher []byte) (gomegatypes.GomegaMatcher, error) {
+	return ParseWithOptions(matcher, nil)
+}
+
+// ParseWithOptions parse matcher of response with options and returns GomegaMatcher
+func ParseWithOptions(matcher []byte, opts *Options) (gomegatypes.GomegaMatcher, error) {
 	var m interface{}
 	if err := json.Unmarshal(matcher, &m); err != nil {
 		return nil, err
 	}
-	return generateMatcher(m)
+	p := newParser(opts)
+	ma, err := p.generateMatcher(m)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.captures.names) == 0 {
+		return ma, nil
+	}
+	return &captureRootMatcher{
+		GomegaMatcher: ma,
+		store:         p.captures,
+	}, nil
 }
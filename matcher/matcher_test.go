@@ -2,8 +2,12 @@ package matcher
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/onsi/gomega"
+	gomegatypes "github.com/onsi/gomega/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -219,6 +223,321 @@ func TestParse(t *testing.T) {
 			}`),
 			true,
 		},
+		{
+			"$contains case -- subset in any order",
+			[]byte(`{
+				"array": {
+					"$contains": ["ccc", "aaa"]
+				}
+			}`),
+			[]byte(`{
+				"array": ["aaa", "bbb", "ccc"]
+			}`),
+			true,
+		},
+		{
+			"$contains case -- missing element",
+			[]byte(`{
+				"array": {
+					"$contains": ["ddd"]
+				}
+			}`),
+			[]byte(`{
+				"array": ["aaa", "bbb", "ccc"]
+			}`),
+			false,
+		},
+		{
+			"$unordered case -- same elements in any order",
+			[]byte(`{
+				"array": {
+					"$unordered": [{"$regexp": "^b"}, "ccc", "aaa"]
+				}
+			}`),
+			[]byte(`{
+				"array": ["aaa", "bbb", "ccc"]
+			}`),
+			true,
+		},
+		{
+			"$unordered case -- unexpected element",
+			[]byte(`{
+				"array": {
+					"$unordered": ["ccc", "aaa"]
+				}
+			}`),
+			[]byte(`{
+				"array": ["aaa", "bbb", "ccc"]
+			}`),
+			false,
+		},
+		{
+			"$unordered case -- pair elements by trying",
+			[]byte(`{
+				"array": {
+					"$unordered": [{"$regexp": "[a-z]+"}, "aaa"]
+				}
+			}`),
+			[]byte(`{
+				"array": ["aaa", "bbb"]
+			}`),
+			true,
+		},
+		{
+			"$unorderedBy case -- match objects by key",
+			[]byte(`{
+				"array": {
+					"$unorderedBy": "id",
+					"$unordered": [
+						{"id": "2", "title": "bbb"},
+						{"id": "1", "title": "aaa"}
+					]
+				}
+			}`),
+			[]byte(`{
+				"array": [
+					{"id": "1", "title": "aaa"},
+					{"id": "2", "title": "bbb"}
+				]
+			}`),
+			true,
+		},
+		{
+			"$unorderedBy case -- element with key is not matched",
+			[]byte(`{
+				"array": {
+					"$unorderedBy": "id",
+					"$contains": [
+						{"id": "2", "title": "aaa"}
+					]
+				}
+			}`),
+			[]byte(`{
+				"array": [
+					{"id": "1", "title": "aaa"},
+					{"id": "2", "title": "bbb"}
+				]
+			}`),
+			false,
+		},
+		{
+			"$each case",
+			[]byte(`{
+				"array": {
+					"$each": {
+						"id": {
+							"$regexp": "^[0-9]+$"
+						}
+					}
+				}
+			}`),
+			[]byte(`{
+				"array": [
+					{"id": "1", "title": "aaa"},
+					{"id": "2", "title": "bbb"}
+				]
+			}`),
+			true,
+		},
+		{
+			"$each case -- one element is not matched",
+			[]byte(`{
+				"array": {
+					"$each": {
+						"$regexp": "^a"
+					}
+				}
+			}`),
+			[]byte(`{
+				"array": ["aaa", "bbb"]
+			}`),
+			false,
+		},
+		{
+			"$any case",
+			[]byte(`{
+				"array": {
+					"$any": {
+						"title": "bbb"
+					}
+				}
+			}`),
+			[]byte(`{
+				"array": [
+					{"id": "1", "title": "aaa"},
+					{"id": "2", "title": "bbb"}
+				]
+			}`),
+			true,
+		},
+		{
+			"$any case -- no element is matched",
+			[]byte(`{
+				"array": {
+					"$any": "ccc"
+				}
+			}`),
+			[]byte(`{
+				"array": ["aaa", "bbb"]
+			}`),
+			false,
+		},
+		{
+			"$type case",
+			[]byte(`{
+				"string": {"$type": "string"},
+				"int": {"$type": "number"},
+				"bool": {"$type": "boolean"},
+				"null": {"$type": "null"},
+				"array": {"$type": "array"},
+				"obj": {"$type": "object"}
+			}`),
+			[]byte(`{
+				"string": "string",
+				"int": 1,
+				"bool": true,
+				"null": null,
+				"array": ["xx"],
+				"obj": {"yy": "yy"}
+			}`),
+			true,
+		},
+		{
+			"$type case -- type is not matched",
+			[]byte(`{
+				"int": {"$type": "string"}
+			}`),
+			[]byte(`{
+				"int": 1
+			}`),
+			false,
+		},
+		{
+			"$oneOf case",
+			[]byte(`{
+				"status": {"$oneOf": ["Running", "Pending"]},
+				"int": {"$oneOf": [1, 2]}
+			}`),
+			[]byte(`{
+				"status": "Pending",
+				"int": 2
+			}`),
+			true,
+		},
+		{
+			"$oneOf case -- not one of literals",
+			[]byte(`{
+				"status": {"$oneOf": ["Running", "Pending"]}
+			}`),
+			[]byte(`{
+				"status": "Failed"
+			}`),
+			false,
+		},
+		{
+			"$or case -- string or null",
+			[]byte(`{
+				"string": {"$or": [null, {"$type": "string"}]},
+				"null": {"$or": [null, {"$type": "string"}]}
+			}`),
+			[]byte(`{
+				"string": "string",
+				"null": null
+			}`),
+			true,
+		},
+		{
+			"$or case -- field is still required",
+			[]byte(`{
+				"string": {"$or": [null, {"$type": "string"}]}
+			}`),
+			[]byte(`{
+			}`),
+			false,
+		},
+		{
+			"$or case -- missing or string",
+			[]byte(`{
+				"string": {"$or": [{"$exists": false}, {"$type": "string"}]},
+				"missing": {"$or": [{"$exists": false}, {"$type": "string"}]}
+			}`),
+			[]byte(`{
+				"string": "string"
+			}`),
+			true,
+		},
+		{
+			"$or case -- missing or string, but number",
+			[]byte(`{
+				"int": {"$or": [{"$exists": false}, {"$type": "string"}]}
+			}`),
+			[]byte(`{
+				"int": 1
+			}`),
+			false,
+		},
+		{
+			"$not case",
+			[]byte(`{
+				"status": {"$not": "Failed"}
+			}`),
+			[]byte(`{
+				"status": "Running"
+			}`),
+			true,
+		},
+		{
+			"$not case -- field is still required",
+			[]byte(`{
+				"status": {"$not": "Failed"}
+			}`),
+			[]byte(`{
+			}`),
+			false,
+		},
+		{
+			"$not case -- negate $exists",
+			[]byte(`{
+				"password": {"$not": {"$exists": true}}
+			}`),
+			[]byte(`{
+				"password": "password"
+			}`),
+			false,
+		},
+		{
+			"$and case -- any non-empty object",
+			[]byte(`{
+				"obj": {"$and": [{"$type": "object"}, {"$not": {"$len": 0}}]}
+			}`),
+			[]byte(`{
+				"obj": {"yy": "yy"}
+			}`),
+			true,
+		},
+		{
+			"$and case -- empty object",
+			[]byte(`{
+				"obj": {"$and": [{"$type": "object"}, {"$not": {"$len": 0}}]}
+			}`),
+			[]byte(`{
+				"obj": {}
+			}`),
+			false,
+		},
+		{
+			"logical case -- nested in array",
+			[]byte(`{
+				"array": [
+					{"$oneOf": ["aaa", "bbb"]},
+					{"$or": [{"$regexp": "^c"}, {"$type": "number"}]}
+				]
+			}`),
+			[]byte(`{
+				"array": ["bbb", 1]
+			}`),
+			true,
+		},
 	}
 
 	for _, c := range cases {
@@ -231,3 +550,660 @@ func TestParse(t *testing.T) {
 		assert.Equal(t, c.res, res, c.desc)
 	}
 }
+
+func TestCollectionFailureMessage(t *testing.T) {
+	cases := []struct {
+		desc    string
+		matcher []byte
+		body    []byte
+		message string
+	}{
+		{
+			"$each names index of element",
+			[]byte(`{"$each": {"$regexp": "^a"}}`),
+			[]byte(`["aaa", "bbb"]`),
+			"[1]",
+		},
+		{
+			"$unorderedBy names index of element",
+			[]byte(`{"$unorderedBy": "id", "$unordered": [{"id": "1"}]}`),
+			[]byte(`[{"id": "1"}, {"id": "2"}]`),
+			"[1]",
+		},
+		{
+			"$contains names index of expected element",
+			[]byte(`{"$contains": ["aaa", "ccc"]}`),
+			[]byte(`["aaa", "bbb"]`),
+			"expected element [1]",
+		},
+	}
+
+	for _, c := range cases {
+		m, err := Parse(c.matcher)
+		require.NoError(t, err, c.desc)
+		var b interface{}
+		require.NoError(t, json.Unmarshal(c.body, &b), c.desc)
+		res, err := m.Match(b)
+		require.NoError(t, err, c.desc)
+		require.False(t, res, c.desc)
+		assert.Contains(t, m.FailureMessage(b), c.message, c.desc)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	cases := []struct {
+		desc    string
+		matcher []byte
+	}{
+		{
+			"$type with unknown type",
+			[]byte(`{"$type": "integer"}`),
+		},
+		{
+			"$oneOf with object",
+			[]byte(`{"$oneOf": [{"aaa": "aaa"}]}`),
+		},
+		{
+			"$or with non-array",
+			[]byte(`{"$or": "aaa"}`),
+		},
+		{
+			"$and can never be matched",
+			[]byte(`{"string": {"$and": [{"$exists": false}, "aaa"]}}`),
+		},
+		{
+			"$capture twice",
+			[]byte(`{"a": {"$capture": "id"}, "b": {"$capture": "id"}}`),
+		},
+		{
+			"$capture with non-string",
+			[]byte(`{"a": {"$capture": 1}}`),
+		},
+	}
+
+	for _, c := range cases {
+		_, err := Parse(c.matcher)
+		assert.Error(t, err, c.desc)
+	}
+}
+
+func TestParseWithOptions(t *testing.T) {
+	cases := []struct {
+		desc    string
+		opts    *Options
+		matcher []byte
+		body    []byte
+		res     bool
+		message string
+	}{
+		{
+			"$strict case -- unexpected field",
+			nil,
+			[]byte(`{
+				"$strict": true,
+				"id": "1"
+			}`),
+			[]byte(`{
+				"id": "1",
+				"password": "password"
+			}`),
+			false,
+			".password",
+		},
+		{
+			"$strict case -- inherited by nested object",
+			nil,
+			[]byte(`{
+				"$strict": true,
+				"id": "1",
+				"items": [
+					{"name": "aaa"}
+				]
+			}`),
+			[]byte(`{
+				"id": "1",
+				"items": [
+					{"name": "aaa", "password": "password"}
+				]
+			}`),
+			false,
+			".items[0].password",
+		},
+		{
+			"$strict case -- disabled in nested object",
+			nil,
+			[]byte(`{
+				"$strict": true,
+				"id": "1",
+				"metadata": {
+					"$strict": false,
+					"name": "aaa"
+				}
+			}`),
+			[]byte(`{
+				"id": "1",
+				"metadata": {
+					"name": "aaa",
+					"uid": "1"
+				}
+			}`),
+			true,
+			"",
+		},
+		{
+			"$allow case",
+			nil,
+			[]byte(`{
+				"$strict": true,
+				"$allow": ["updatedAt", "metadata.resourceVersion"],
+				"id": "1",
+				"metadata": {
+					"name": "aaa"
+				}
+			}`),
+			[]byte(`{
+				"id": "1",
+				"updatedAt": "2018-01-01T00:00:00Z",
+				"metadata": {
+					"name": "aaa",
+					"resourceVersion": "1"
+				}
+			}`),
+			true,
+			"",
+		},
+		{
+			"strict option",
+			&Options{
+				Strict:        true,
+				AllowedFields: []string{"items.*.uid"},
+			},
+			[]byte(`{
+				"id": "1",
+				"items": [
+					{"name": "aaa"}
+				]
+			}`),
+			[]byte(`{
+				"id": "1",
+				"password": "password",
+				"items": [
+					{"name": "aaa", "uid": "1"}
+				]
+			}`),
+			false,
+			".password",
+		},
+		{
+			"strict option -- any element of array",
+			&Options{
+				Strict:        true,
+				AllowedFields: []string{"items.*.y"},
+			},
+			[]byte(`{"a": 1, "items": [{"x": 1}]}`),
+			[]byte(`{"a": 1, "items": [{"x": 1, "y": 2}]}`),
+			true,
+			"",
+		},
+		{
+			"strict option -- index of array",
+			&Options{
+				Strict:        true,
+				AllowedFields: []string{"items.1.y"},
+			},
+			[]byte(`{"items": [{"x": 1}, {"x": 2}]}`),
+			[]byte(`{"items": [{"x": 1, "y": 2}, {"x": 2, "y": 3}]}`),
+			false,
+			".items[0].y",
+		},
+		{
+			"strict option -- element of $each",
+			&Options{
+				Strict:        true,
+				AllowedFields: []string{"items.*.y"},
+			},
+			[]byte(`{"items": {"$each": {"x": {"$type": "number"}}}}`),
+			[]byte(`{"items": [{"x": 1, "y": 2}, {"x": 2, "y": 3}]}`),
+			true,
+			"",
+		},
+		{
+			"strict option -- array is not skipped",
+			&Options{
+				Strict:        true,
+				AllowedFields: []string{"items.y"},
+			},
+			[]byte(`{"items": [{"x": 1}]}`),
+			[]byte(`{"items": [{"x": 1, "y": 2}]}`),
+			false,
+			".items[0].y",
+		},
+	}
+
+	for _, c := range cases {
+		m, err := ParseWithOptions(c.matcher, c.opts)
+		require.NoError(t, err, c.desc)
+		var b interface{}
+		require.NoError(t, json.Unmarshal(c.body, &b), c.desc)
+		res, err := m.Match(b)
+		require.NoError(t, err, c.desc)
+		assert.Equal(t, c.res, res, c.desc)
+		if !c.res {
+			assert.Contains(t, m.FailureMessage(b), c.message, c.desc)
+		}
+	}
+}
+
+func TestTimeMatcher(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		desc    string
+		matcher string
+		body    string
+		res     bool
+		message string
+	}{
+		{
+			"$timeFormat case -- RFC3339",
+			`{"$timeFormat": "RFC3339"}`,
+			`"2018-01-01T00:00:00Z"`,
+			true,
+			"",
+		},
+		{
+			"$timeFormat case -- invalid time",
+			`{"$timeFormat": "RFC3339"}`,
+			`"2018-01-01"`,
+			false,
+			"to be a time in format RFC3339",
+		},
+		{
+			"$timeFormat case -- golang layout",
+			`{"$timeFormat": "2006-01-02"}`,
+			`"2018-01-01"`,
+			true,
+			"",
+		},
+		{
+			"$time case -- within",
+			`{"$time": {"within": "1m"}}`,
+			fmt.Sprintf(`"%v"`, now.Add(-10*time.Second).Format(time.RFC3339)),
+			true,
+			"",
+		},
+		{
+			"$time case -- not within",
+			`{"$time": {"within": "1m"}}`,
+			fmt.Sprintf(`"%v"`, now.Add(-10*time.Minute).Format(time.RFC3339)),
+			false,
+			"to be within 1m0s of now",
+		},
+		{
+			"$time case -- unix after",
+			`{"$time": {"format": "unix", "after": "2018-01-01T00:00:00Z"}}`,
+			fmt.Sprintf("%v", now.Unix()),
+			true,
+			"",
+		},
+		{
+			"$time case -- unixMilli before",
+			`{"$time": {"format": "unixMilli", "before": 1514764800000}}`,
+			fmt.Sprintf("%v", now.UnixNano()/int64(time.Millisecond)),
+			false,
+			"to be before 2018-01-01T00:00:00Z",
+		},
+		{
+			"$time case -- relative after",
+			`{"$time": {"after": "now+720h"}}`,
+			fmt.Sprintf(`"%v"`, now.Add(365*24*time.Hour).Format(time.RFC3339)),
+			true,
+			"",
+		},
+		{
+			"$time case -- relative before",
+			`{"$time": {"before": "now-1h"}}`,
+			fmt.Sprintf(`"%v"`, now.Format(time.RFC3339)),
+			false,
+			"to be before",
+		},
+		{
+			"$time case -- after and before",
+			`{"$time": {"after": "2018-01-01T00:00:00Z", "before": "2018-01-02T00:00:00Z"}}`,
+			`"2018-01-01T12:00:00+08:00"`,
+			true,
+			"",
+		},
+	}
+
+	for _, c := range cases {
+		m, err := Parse([]byte(c.matcher))
+		require.NoError(t, err, c.desc)
+		var b interface{}
+		require.NoError(t, json.Unmarshal([]byte(c.body), &b), c.desc)
+		res, err := m.Match(b)
+		require.NoError(t, err, c.desc)
+		assert.Equal(t, c.res, res, c.desc)
+		if !c.res {
+			assert.Contains(t, m.FailureMessage(b), c.message, c.desc)
+		}
+	}
+}
+
+func TestRelativeTimeMatcher(t *testing.T) {
+	m, err := Parse([]byte(`{"$time": {"format": "RFC3339Nano", "after": "now-1s", "before": "now+1s"}}`))
+	require.NoError(t, err)
+	time.Sleep(1500 * time.Millisecond)
+
+	// now is resolved when matching instead of parsing
+	res, err := m.Match(time.Now().Format(time.RFC3339Nano))
+	require.NoError(t, err)
+	assert.True(t, res)
+
+	_, err = Parse([]byte(`{"$time": {"after": "now*2h"}}`))
+	assert.Error(t, err)
+}
+
+func TestCaptureMatcher(t *testing.T) {
+	cases := []struct {
+		desc     string
+		matcher  string
+		body     string
+		res      bool
+		captures map[string]interface{}
+	}{
+		{
+			"$capture case -- string",
+			`{"id": {"$capture": "productId"}, "title": "test"}`,
+			`{"id": "p-123", "title": "test"}`,
+			true,
+			map[string]interface{}{
+				"productId": "p-123",
+			},
+		},
+		{
+			"$capture case -- with other matchers",
+			`{"id": {"$regexp": "^p-", "$capture": "productId"}, "meta": {"$type": "object", "$capture": "meta"}}`,
+			`{"id": "p-123", "meta": {"version": 1}}`,
+			true,
+			map[string]interface{}{
+				"productId": "p-123",
+				"meta": map[string]interface{}{
+					"version": float64(1),
+				},
+			},
+		},
+		{
+			"$capture case -- element chosen by $any",
+			`{"items": {"$any": {"name": "bbb", "id": {"$capture": "itemId"}}}}`,
+			`{"items": [{"name": "aaa", "id": 1}, {"name": "bbb", "id": 2}]}`,
+			true,
+			map[string]interface{}{
+				"itemId": float64(2),
+			},
+		},
+		{
+			"$capture case -- not matched",
+			`{"id": {"$regexp": "^p-", "$capture": "productId"}}`,
+			`{"id": "q-123"}`,
+			false,
+			map[string]interface{}{},
+		},
+		{
+			"$capture case -- failed branch of $or",
+			`{"$or": [{"id": {"$capture": "first"}, "kind": "a"}, {"id": {"$capture": "second"}, "kind": "b"}]}`,
+			`{"id": 1, "kind": "b"}`,
+			true,
+			map[string]interface{}{
+				"second": float64(1),
+			},
+		},
+		{
+			"$capture case -- element paired by $unordered",
+			`{"items": {"$unordered": [{"id": {"$capture": "anyId"}}, {"id": 1}]}}`,
+			`{"items": [{"id": 1}, {"id": 2}]}`,
+			true,
+			map[string]interface{}{
+				"anyId": float64(2),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		m, err := Parse([]byte(c.matcher))
+		require.NoError(t, err, c.desc)
+		var b interface{}
+		require.NoError(t, json.Unmarshal([]byte(c.body), &b), c.desc)
+		res, err := m.Match(b)
+		require.NoError(t, err, c.desc)
+		assert.Equal(t, c.res, res, c.desc)
+		capturer, ok := m.(Capturer)
+		require.True(t, ok, c.desc)
+		assert.Equal(t, c.captures, capturer.Captures(), c.desc)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	require.NoError(t, Register("lowercase", func(arg interface{}) (gomegatypes.GomegaMatcher, error) {
+		b, ok := arg.(bool)
+		if !ok {
+			return nil, fmt.Errorf("value MUST be bool")
+		}
+		if !b {
+			return gomega.Not(gomega.MatchRegexp("^[a-z]+$")), nil
+		}
+		return gomega.MatchRegexp("^[a-z]+$"), nil
+	}))
+	assert.Error(t, Register("$lowercase", func(arg interface{}) (gomegatypes.GomegaMatcher, error) {
+		return nil, nil
+	}), "duplicated matcher")
+	assert.Error(t, Register("regexp", func(arg interface{}) (gomegatypes.GomegaMatcher, error) {
+		return nil, nil
+	}), "builtin matcher")
+
+	cases := []struct {
+		desc    string
+		matcher string
+		body    string
+		res     bool
+	}{
+		{
+			"custom matcher case -- matched",
+			`{"name": {"$lowercase": true}}`,
+			`{"name": "aaa"}`,
+			true,
+		},
+		{
+			"custom matcher case -- not matched",
+			`{"name": {"$lowercase": true}}`,
+			`{"name": "Aaa"}`,
+			false,
+		},
+		{
+			"custom matcher case -- with builtin matcher",
+			`{"name": {"$lowercase": false, "$len": 3}}`,
+			`{"name": "Aaa"}`,
+			true,
+		},
+		{
+			"custom matcher case -- field not exists",
+			`{"name": {"$lowercase": true}}`,
+			`{}`,
+			false,
+		},
+	}
+	for _, c := range cases {
+		m, err := Parse([]byte(c.matcher))
+		require.NoError(t, err, c.desc)
+		var b interface{}
+		require.NoError(t, json.Unmarshal([]byte(c.body), &b), c.desc)
+		res, err := m.Match(b)
+		require.NoError(t, err, c.desc)
+		assert.Equal(t, c.res, res, c.desc)
+	}
+
+	_, err := Parse([]byte(`{"name": {"$lowercase": "aaa"}}`))
+	assert.Error(t, err, "invalid arg of custom matcher")
+	_, err = Parse([]byte(`{"name": {"$uppercase": true}}`))
+	assert.Error(t, err, "unknown matcher")
+}
+
+func TestPathMatcher(t *testing.T) {
+	body := `{"items": [{"id": "1", "name": "aaa", "replicas": 1}, {"id": "2", "name": "bbb", "replicas": 3}]}`
+	cases := []struct {
+		desc    string
+		matcher string
+		res     bool
+		message string
+	}{
+		{
+			"$path case -- definite path",
+			`{"$path": {"$.items[1].name": "bbb"}}`,
+			true,
+			"",
+		},
+		{
+			"$path case -- wildcard",
+			`{"$path": {"$.items[*].id": ["1", "2"]}}`,
+			true,
+			"",
+		},
+		{
+			"$path case -- filter",
+			`{"$path": {"$.items[?(@.replicas > 1)].name": {"$contains": ["bbb"]}}}`,
+			true,
+			"",
+		},
+		{
+			"$path case -- filter with nested matcher",
+			`{"$path": {"$.items[?(@.name == 'aaa')]": {"$each": {"replicas": {"$type": "number"}}}}}`,
+			true,
+			"",
+		},
+		{
+			"$path case -- not matched",
+			`{"$path": {"$.items[?(@.name == 'aaa')].replicas": [3]}}`,
+			false,
+			"($.items[?(@.name == 'aaa')].replicas)[0]",
+		},
+		{
+			"$path case -- definite path selects nothing",
+			`{"$path": {"$.items[5].name": "ccc"}}`,
+			false,
+			"selects nothing",
+		},
+	}
+
+	for _, c := range cases {
+		m, err := Parse([]byte(c.matcher))
+		require.NoError(t, err, c.desc)
+		var b interface{}
+		require.NoError(t, json.Unmarshal([]byte(body), &b), c.desc)
+		res, err := m.Match(b)
+		require.NoError(t, err, c.desc)
+		assert.Equal(t, c.res, res, c.desc)
+		if !c.res {
+			assert.Contains(t, m.FailureMessage(b), c.message, c.desc)
+		}
+	}
+
+	_, err := Parse([]byte(`{"$path": {"items": 1}}`))
+	assert.Error(t, err, "invalid jsonpath")
+}
+
+func TestFlatten(t *testing.T) {
+	cases := []struct {
+		desc     string
+		matcher  string
+		body     string
+		failures []string
+	}{
+		{
+			"nested field",
+			`{"items": [{"spec": {"replicas": 3}}, {"spec": {"replicas": 3}}]}`,
+			`{"items": [{"spec": {"replicas": 3}}, {"spec": {"replicas": 2}}]}`,
+			[]string{"items[1].spec.replicas: expected 3, got 2"},
+		},
+		{
+			"multiple fields",
+			`{"name": "aaa", "enabled": true, "deleted": null}`,
+			`{"name": "bbb", "enabled": true, "deleted": "1"}`,
+			[]string{
+				`deleted: expected null, got "1"`,
+				`name: expected "aaa", got "bbb"`,
+			},
+		},
+		{
+			"special matcher",
+			`{"id": {"$regexp": "^p-"}, "tags": {"$each": {"$type": "string"}}}`,
+			`{"id": "q-1", "tags": ["a", 1]}`,
+			[]string{
+				`id: Expected <string>: q-1 to match regular expression <string>: ^p-`,
+				`tags[1]: Expected <float64>: 1 to be string, actual type is number`,
+			},
+		},
+		{
+			"field existence",
+			`{"name": "aaa", "password": {"$exists": false}}`,
+			`{"password": "aaa"}`,
+			[]string{
+				`name: field existence err, expected: true, actual: false`,
+				`password: field existence err, expected: false, actual: true`,
+			},
+		},
+		{
+			"$and and $or",
+			`{"name": {"$and": [{"$len": 3}, {"$or": ["aaa", "bbb"]}]}}`,
+			`{"name": "ccc"}`,
+			[]string{
+				`name: Expected <string>: ccc to match at least one of matchers, but none matched: ` +
+					`{ [0]: Expected <string>: ccc to equal <string>: aaa [1]: Expected <string>: ccc to equal <string>: bbb }`,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		m, err := Parse([]byte(c.matcher))
+		require.NoError(t, err, c.desc)
+		var b interface{}
+		require.NoError(t, json.Unmarshal([]byte(c.body), &b), c.desc)
+		res, err := m.Match(b)
+		require.NoError(t, err, c.desc)
+		require.False(t, res, c.desc)
+		failures := []string{}
+		for _, f := range Flatten(m, b) {
+			failures = append(failures, f.String())
+		}
+		assert.Equal(t, c.failures, failures, c.desc)
+	}
+}
+
+func TestExpectedView(t *testing.T) {
+	matcher := `{"id": {"$regexp": "^p-"}, "name": "aaa", "items": [{"id": 1}, {"id": {"$type": "string"}}]}`
+	body := `{"id": "p-1", "name": "bbb", "createdAt": "2018", "items": [{"id": 1, "name": "a"}, {"id": 2}]}`
+	expected := `{"id": "p-1", "name": "aaa", "createdAt": "2018", "items": [{"id": 1, "name": "a"}, {"id": {"$type": "string"}}]}`
+
+	m, err := Parse([]byte(matcher))
+	require.NoError(t, err)
+	var e, b, view interface{}
+	require.NoError(t, json.Unmarshal([]byte(matcher), &e))
+	require.NoError(t, json.Unmarshal([]byte(body), &b))
+	require.NoError(t, json.Unmarshal([]byte(expected), &view))
+	res, err := m.Match(b)
+	require.NoError(t, err)
+	require.False(t, res)
+	assert.Equal(t, view, ExpectedView(e, b, Flatten(m, b)))
+}
+
+func TestCaptureMatcherError(t *testing.T) {
+	cases := []struct {
+		desc    string
+		matcher string
+	}{
+		{"$capture in $not", `{"id": {"$not": {"$capture": "id"}}}`},
+		{"$capture twice", `{"a": {"$capture": "id"}, "b": {"$capture": "id"}}`},
+	}
+	for _, c := range cases {
+		_, err := Parse([]byte(c.matcher))
+		assert.Error(t, err, c.desc)
+	}
+}
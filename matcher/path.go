@@ -0,0 +1,99 @@
+package matcher
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/caicloud/aloe/utils/jsonpath"
+	"github.com/onsi/gomega/format"
+	errorsutil "github.com/onsi/gomega/gstruct/errors"
+	"github.com/onsi/gomega/types"
+)
+
+func (p *parser) generatePathMatcher(expr interface{}) (types.GomegaMatcher, error) {
+	m, ok := convertToMap(expr)
+	if !ok || len(m) == 0 {
+		return nil, fmt.Errorf("value of $path MUST be a non-empty object, actual: %v", expr)
+	}
+	// selected values are not fields of current object, so allowed paths are not inherited
+	np := &parser{
+		strict:   p.strict,
+		captures: p.captures,
+	}
+	paths := map[string]types.GomegaMatcher{}
+	for k, v := range m {
+		ma, err := np.generateMatcher(v)
+		if err != nil {
+			return nil, err
+		}
+		paths[k] = ma
+	}
+	return MatchPaths(paths)
+}
+
+// MatchPaths succeeds if values selected by every jsonpath match the matcher
+// If jsonpath is definite, e.g. $.a.b, the selected value is matched
+// Otherwise, e.g. $.items[*].id, an array of all selected values is matched
+func MatchPaths(paths map[string]types.GomegaMatcher) (types.GomegaMatcher, error) {
+	pm := &JSONPathMatcher{
+		Matchers: paths,
+		paths:    map[string]*jsonpath.Path{},
+	}
+	for k := range paths {
+		path, err := jsonpath.Parse(k)
+		if err != nil {
+			return nil, err
+		}
+		pm.paths[k] = path
+	}
+	return pm, nil
+}
+
+// JSONPathMatcher is a NestingMatcher that matches values selected by jsonpath
+type JSONPathMatcher struct {
+	// Matchers defines matcher of every jsonpath
+	Matchers map[string]types.GomegaMatcher
+
+	paths map[string]*jsonpath.Path
+
+	// State.
+	failures []error
+}
+
+// Match implements types.GomegaMatcher
+func (m *JSONPathMatcher) Match(actual interface{}) (bool, error) {
+	m.failures = nil
+	keys := make([]string, 0, len(m.Matchers))
+	for k := range m.Matchers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := fmt.Sprintf("(%v)", k)
+		v, err := m.paths[k].Select(actual)
+		if err != nil {
+			m.failures = append(m.failures, errorsutil.Nest(path, err))
+			continue
+		}
+		if err := matchElement(m.Matchers[k], v); err != nil {
+			m.failures = append(m.failures, errorsutil.Nest(path, err))
+		}
+	}
+	return len(m.failures) == 0, nil
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *JSONPathMatcher) FailureMessage(actual interface{}) (message string) {
+	failure := errorsutil.AggregateError(m.failures)
+	return format.Message(actual, fmt.Sprintf("to match jsonpath: %v", failure))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *JSONPathMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return format.Message(actual, "not to match jsonpath")
+}
+
+// Failures returns failures of matcher
+func (m *JSONPathMatcher) Failures() []error {
+	return m.failures
+}
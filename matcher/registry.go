@@ -0,0 +1,89 @@
+package matcher
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/onsi/gomega/types"
+)
+
+// Factory generates a matcher from value of the special matcher key
+// e.g. arg of {"$k8sName": true} is true
+type Factory func(arg interface{}) (types.GomegaMatcher, error)
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]Factory{}
+)
+
+// builtins defines names of special matchers which can't be registered
+var builtins = map[string]struct{}{
+	ExistsMatcher:      {},
+	RegexpMatcher:      {},
+	MatchMatcher:       {},
+	LenMatcher:         {},
+	ContainsMatcher:    {},
+	UnorderedMatcher:   {},
+	UnorderedByMatcher: {},
+	EachMatcher:        {},
+	AnyMatcher:         {},
+	TypeMatcher:        {},
+	OneOfMatcher:       {},
+	NotMatcher:         {},
+	OrMatcher:          {},
+	AndMatcher:         {},
+	TimeMatcher:        {},
+	TimeFormatMatcher:  {},
+	CaptureMatcher:     {},
+	PathMatcher:        {},
+	StrictModifier:     {},
+	AllowModifier:      {},
+}
+
+// Register registers a custom special matcher
+// Name will be prefixed by $ if it is not, e.g. k8sName => $k8sName
+func Register(name string, f Factory) error {
+	if f == nil {
+		return fmt.Errorf("can't register matcher %v: factory is nil", name)
+	}
+	if !strings.HasPrefix(name, "$") {
+		name = "$" + name
+	}
+	if name == "$" {
+		return fmt.Errorf("can't register matcher with empty name")
+	}
+	if _, ok := builtins[name]; ok {
+		return fmt.Errorf("can't register matcher %v: it is a builtin matcher", name)
+	}
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := registry[name]; ok {
+		return fmt.Errorf("can't register matcher %v: already exists", name)
+	}
+	registry[name] = f
+	return nil
+}
+
+// lookup returns factory of registered matcher
+func lookup(name string) (Factory, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	f, ok := registry[name]
+	return f, ok
+}
+
+func generateCustomMatcher(key string, expr interface{}) (types.GomegaMatcher, error) {
+	f, ok := lookup(key)
+	if !ok {
+		return nil, fmt.Errorf("unknown special matcher: %v", key)
+	}
+	ma, err := f(expr)
+	if err != nil {
+		return nil, fmt.Errorf("can't generate matcher %v: %v", key, err)
+	}
+	if ma == nil {
+		return nil, fmt.Errorf("can't generate matcher %v: factory returns nil", key)
+	}
+	return ma, nil
+}
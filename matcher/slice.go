@@ -1,7 +1,6 @@
 package matcher
 
 import (
-	"errors"
 	"fmt"
 	"reflect"
 	"runtime/debug"
@@ -74,11 +73,7 @@ func (m *SliceMatcher) matchElements(actual interface{}) (errs []error) {
 		}
 
 		if err == nil {
-			if nesting, ok := matcher.(errorsutil.NestingMatcher); ok {
-				err = errorsutil.AggregateError(nesting.Failures())
-			} else {
-				err = errors.New(matcher.FailureMessage(element))
-			}
+			err = failureOf(matcher, element)
 		}
 		errs = append(errs, errorsutil.Nest(fmt.Sprintf("[%v]", i), err))
 	}
@@ -1,7 +1,6 @@
 package matcher
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/onsi/gomega/format"
@@ -25,6 +24,7 @@ type SpecialMatcher struct {
 
 // Match implements types.GomegaMatcher
 func (sp *SpecialMatcher) Match(actual interface{}) (bool, error) {
+	sp.failures = nil
 	for k, m := range sp.ms {
 		match, err := m.Match(actual)
 		if err != nil {
@@ -33,11 +33,7 @@ func (sp *SpecialMatcher) Match(actual interface{}) (bool, error) {
 		if match {
 			continue
 		}
-		if nesting, ok := m.(errorsutil.NestingMatcher); ok {
-			err = errorsutil.AggregateError(nesting.Failures())
-		} else {
-			err = errors.New(m.FailureMessage(actual))
-		}
+		err = failureOf(m, actual)
 		sp.failures = append(sp.failures, errorsutil.Nest(k, err))
 	}
 	if len(sp.failures) > 0 {
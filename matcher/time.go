@@ -0,0 +1,250 @@
+package matcher
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+const (
+	// UnixFormat means time is seconds since epoch
+	UnixFormat = "unix"
+	// UnixMilliFormat means time is milliseconds since epoch
+	UnixMilliFormat = "unixMilli"
+
+	// relativeTimePrefix is prefix of time relative to now
+	relativeTimePrefix = "now"
+)
+
+// timeFormats defines named layouts of time
+var timeFormats = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"ANSIC":       time.ANSIC,
+}
+
+// parseTime parses time from value of json by format
+// format can be a name of layout, e.g. RFC3339, unix, unixMilli or a golang layout
+func parseTime(format string, value interface{}) (time.Time, error) {
+	if format == "" {
+		format = "RFC3339"
+	}
+	switch format {
+	case UnixFormat, UnixMilliFormat:
+		var n float64
+		switch v := value.(type) {
+		case float64:
+			n = v
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("can't parse %v as %v time: %v", v, format, err)
+			}
+			n = f
+		default:
+			return time.Time{}, fmt.Errorf("%v time MUST be a number or string, actual: %T", format, value)
+		}
+		if format == UnixMilliFormat {
+			return time.Unix(0, int64(n*float64(time.Millisecond))), nil
+		}
+		return time.Unix(0, int64(n*float64(time.Second))), nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%v time MUST be a string, actual: %T", format, value)
+	}
+	layout, ok := timeFormats[format]
+	if !ok {
+		layout = format
+	}
+	return time.Parse(layout, s)
+}
+
+func generateTimeMatcher(expr interface{}) (types.GomegaMatcher, error) {
+	m, ok := convertToMap(expr)
+	if !ok {
+		return nil, fmt.Errorf("value of $time MUST be an object, actual: %T", expr)
+	}
+	tm := &TimestampMatcher{}
+	for k, v := range m {
+		switch k {
+		case "format":
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("format of $time MUST be a string, actual: %T", v)
+			}
+			tm.Format = s
+		case "within":
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("within of $time MUST be a duration string, actual: %T", v)
+			}
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, fmt.Errorf("within of $time MUST be a duration string: %v", err)
+			}
+			tm.Within = &d
+		case "after", "before":
+		default:
+			return nil, fmt.Errorf("unknown key of $time: %v, only [format, within, after, before] is allowed", k)
+		}
+	}
+	for _, k := range []string{"after", "before"} {
+		v, ok := m[k]
+		if !ok {
+			continue
+		}
+		offset, ok, err := parseRelativeTime(v)
+		if err != nil {
+			return nil, fmt.Errorf("%v of $time can't be parsed: %v", k, err)
+		}
+		if ok {
+			// now is resolved when matching
+			if k == "after" {
+				tm.AfterNow = &offset
+			} else {
+				tm.BeforeNow = &offset
+			}
+			continue
+		}
+		t, err := parseTime(tm.Format, v)
+		if err != nil {
+			// fall back to RFC3339, e.g. a unix time after a RFC3339 time
+			var rfcErr error
+			t, rfcErr = parseTime("", v)
+			if rfcErr != nil {
+				return nil, fmt.Errorf("%v of $time can't be parsed: %v", k, err)
+			}
+		}
+		if k == "after" {
+			tm.After = &t
+		} else {
+			tm.Before = &t
+		}
+	}
+	return tm, nil
+}
+
+// parseRelativeTime parses offset of time relative to now, e.g. now, now+720h or now-1h
+// It returns false if value is not a relative time
+func parseRelativeTime(value interface{}) (time.Duration, bool, error) {
+	s, ok := value.(string)
+	if !ok || !strings.HasPrefix(s, relativeTimePrefix) {
+		return 0, false, nil
+	}
+	offset := strings.TrimSpace(strings.TrimPrefix(s, relativeTimePrefix))
+	if offset == "" {
+		return 0, true, nil
+	}
+	if offset[0] != '+' && offset[0] != '-' {
+		return 0, false, fmt.Errorf("relative time MUST be in format now+duration or now-duration, actual: %v", s)
+	}
+	d, err := time.ParseDuration(offset)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid duration of relative time %v: %v", s, err)
+	}
+	return d, true, nil
+}
+
+func generateTimeFormatMatcher(expr interface{}) (types.GomegaMatcher, error) {
+	s, ok := expr.(string)
+	if !ok {
+		return nil, fmt.Errorf("value of $timeFormat MUST be a string, actual: %T", expr)
+	}
+	return MatchTime(s), nil
+}
+
+// MatchTime succeeds if actual is a time in format
+func MatchTime(format string) types.GomegaMatcher {
+	return &TimestampMatcher{
+		Format: format,
+	}
+}
+
+// TimestampMatcher is a matcher to check time in json
+type TimestampMatcher struct {
+	// Format defines format of time
+	// It can be a name of layout, e.g. RFC3339, unix, unixMilli or a golang layout
+	// Default is RFC3339
+	Format string
+
+	// Within defines max duration between time and now
+	Within *time.Duration
+
+	// After defines time should be after it
+	After *time.Time
+
+	// Before defines time should be before it
+	Before *time.Time
+
+	// AfterNow defines time should be after now plus it
+	AfterNow *time.Duration
+
+	// BeforeNow defines time should be before now plus it
+	BeforeNow *time.Duration
+
+	// State.
+	failure string
+}
+
+// Match implements types.GomegaMatcher
+func (m *TimestampMatcher) Match(actual interface{}) (bool, error) {
+	m.failure = ""
+	f := m.Format
+	if f == "" {
+		f = "RFC3339"
+	}
+	t, err := parseTime(f, actual)
+	if err != nil {
+		m.failure = fmt.Sprintf("to be a time in format %v: %v", f, err)
+		return false, nil
+	}
+	if m.Within != nil {
+		now := time.Now()
+		d := now.Sub(t)
+		if d < 0 {
+			d = -d
+		}
+		if d > *m.Within {
+			m.failure = fmt.Sprintf("to be within %v of now(%v), actual: %v", *m.Within, now.Format(time.RFC3339), t.Format(time.RFC3339))
+			return false, nil
+		}
+	}
+	after, before := m.After, m.Before
+	if m.AfterNow != nil {
+		a := time.Now().Add(*m.AfterNow)
+		after = &a
+	}
+	if m.BeforeNow != nil {
+		b := time.Now().Add(*m.BeforeNow)
+		before = &b
+	}
+	if after != nil && !t.After(*after) {
+		m.failure = fmt.Sprintf("to be after %v, actual: %v", after.Format(time.RFC3339Nano), t.Format(time.RFC3339Nano))
+		return false, nil
+	}
+	if before != nil && !t.Before(*before) {
+		m.failure = fmt.Sprintf("to be before %v, actual: %v", before.Format(time.RFC3339Nano), t.Format(time.RFC3339Nano))
+		return false, nil
+	}
+	return true, nil
+}
+
+// FailureMessage implements types.GomegaMatcher
+func (m *TimestampMatcher) FailureMessage(actual interface{}) (message string) {
+	return format.Message(actual, m.failure)
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher
+func (m *TimestampMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return format.Message(actual, "not to match time")
+}
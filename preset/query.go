@@ -0,0 +1,37 @@
+package preset
+
+import (
+	"net/url"
+
+	"github.com/caicloud/aloe/runtime"
+)
+
+type queryPresetter struct {
+}
+
+// NewQueryPresetter returns query presetter
+// It adds default query parameters to round trips
+func NewQueryPresetter() Presetter {
+	return &queryPresetter{}
+}
+
+// Name implements preset.Presetter
+func (p *queryPresetter) Name() string {
+	return "query"
+}
+
+// Preset implements preset.Presetter
+func (p *queryPresetter) Preset(rt *runtime.RoundTripTemplate, args map[string]string) (*runtime.RoundTripTemplate, error) {
+	if rt == nil {
+		rt = &runtime.RoundTripTemplate{}
+	}
+
+	if rt.Request.Query == nil {
+		rt.Request.Query = url.Values{}
+	}
+	for k, v := range args {
+		rt.Request.Query.Set(k, v)
+	}
+
+	return rt, nil
+}
@@ -0,0 +1,92 @@
+package roundtrip
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/caicloud/aloe/runtime"
+)
+
+// matchBinary checks body as binary and returns failures
+func matchBinary(b *runtime.Binary, body []byte) []string {
+	failures := []string{}
+	if b.Length != nil && int64(len(body)) != *b.Length {
+		failures = append(failures, fmt.Sprintf("length is not matched, expected: %v, actual: %v", *b.Length, len(body)))
+	}
+	if b.SHA256 != "" {
+		sum := sha256.Sum256(body)
+		if actual := hex.EncodeToString(sum[:]); actual != b.SHA256 {
+			failures = append(failures, fmt.Sprintf("sha256 is not matched, expected: %v, actual: %v", b.SHA256, actual))
+		}
+	}
+	if b.MD5 != "" {
+		sum := md5.Sum(body)
+		if actual := hex.EncodeToString(sum[:]); actual != b.MD5 {
+			failures = append(failures, fmt.Sprintf("md5 is not matched, expected: %v, actual: %v", b.MD5, actual))
+		}
+	}
+	if b.ContentType != "" {
+		actual := http.DetectContentType(body)
+		if mediaType(actual) != mediaType(b.ContentType) {
+			failures = append(failures, fmt.Sprintf("detected content type is not matched, expected: %v, actual: %v", b.ContentType, actual))
+		}
+	}
+	return failures
+}
+
+// mediaType returns media type without parameters
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return mt
+}
+
+// savedBodies defines temp dir of bodies saved in current run
+var savedBodies = struct {
+	sync.Mutex
+	dir string
+}{}
+
+// saveBody saves body to a temp file and returns its path
+// Files are created in a temp dir which is removed by RemoveSavedBodies
+func saveBody(body []byte) (string, error) {
+	savedBodies.Lock()
+	defer savedBodies.Unlock()
+	if savedBodies.dir == "" {
+		dir, err := ioutil.TempDir("", "aloe-")
+		if err != nil {
+			return "", err
+		}
+		savedBodies.dir = dir
+	}
+	f, err := ioutil.TempFile(savedBodies.dir, "body-")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(body); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// RemoveSavedBodies removes all files of bodies saved by saveTo
+func RemoveSavedBodies() error {
+	savedBodies.Lock()
+	defer savedBodies.Unlock()
+	if savedBodies.dir == "" {
+		return nil
+	}
+	dir := savedBodies.dir
+	savedBodies.dir = ""
+	return os.RemoveAll(dir)
+}
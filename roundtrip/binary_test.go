@@ -0,0 +1,79 @@
+package roundtrip
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/caicloud/aloe/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchBinary(t *testing.T) {
+	body := []byte("\x89PNG\x0d\x0a\x1a\x0a")
+	length := int64(len(body))
+	wrongLength := int64(1)
+	cases := []struct {
+		desc     string
+		binary   runtime.Binary
+		failures int
+	}{
+		{
+			"all matched",
+			runtime.Binary{
+				Length:      &length,
+				SHA256:      "4c4b6a3be1314ab86138bef4314dde022e600960d8689a2c8f8631802d20dab6",
+				MD5:         "e9dd2797018cad79186e03e8c5aec8dc",
+				ContentType: "image/png",
+			},
+			0,
+		},
+		{
+			"wrong length",
+			runtime.Binary{Length: &wrongLength},
+			1,
+		},
+		{
+			"wrong content type",
+			runtime.Binary{ContentType: "application/x-gzip"},
+			1,
+		},
+		{
+			"nothing to check",
+			runtime.Binary{},
+			0,
+		},
+	}
+	for _, c := range cases {
+		failures := matchBinary(&c.binary, body)
+		assert.Len(t, failures, c.failures, "%v: %v", c.desc, failures)
+	}
+}
+
+func TestSaveBody(t *testing.T) {
+	body := "\x1f\x8b\x08\x00tarball"
+	m, err := MatchResponse(&runtime.RoundTrip{
+		RoundTripTemplate: runtime.RoundTripTemplate{
+			Response: runtime.Response{
+				Binary: &runtime.Binary{ContentType: "application/x-gzip"},
+				SaveTo: "chart",
+			},
+		},
+	})
+	require.NoError(t, err)
+	matched, err := m.Match(newResponse(200, "application/octet-stream", body))
+	require.NoError(t, err)
+	require.True(t, matched, m.FailureMessage(nil))
+
+	v, ok := m.Variables()["chart"]
+	require.True(t, ok)
+	saved, err := ioutil.ReadFile(v.String())
+	require.NoError(t, err)
+	assert.Equal(t, body, string(saved))
+
+	require.NoError(t, RemoveSavedBodies())
+	_, err = os.Stat(v.String())
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, RemoveSavedBodies())
+}
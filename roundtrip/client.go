@@ -0,0 +1,139 @@
+package roundtrip
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/caicloud/aloe/runtime"
+)
+
+// NewClient builds http client from config
+func NewClient(c *runtime.Client) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.Proxy != "" {
+		u, err := url.Parse(c.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %v: %v", c.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+	transport.DisableKeepAlives = c.DisableKeepAlives
+	if c.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+	transport.MaxConnsPerHost = c.MaxConnsPerHost
+	if c.TLS != nil {
+		tlsConfig, err := newTLSConfig(c.TLS)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   c.Timeout,
+	}
+	if c.MaxRedirects != nil {
+		client.CheckRedirect = checkRedirect(*c.MaxRedirects)
+	}
+	return client, nil
+}
+
+// WithTLS returns a copy of client whose transport uses tls config
+// Other settings of client, e.g. jar and redirect policy, are kept
+func WithTLS(c *http.Client, t *runtime.TLS) (*http.Client, error) {
+	base := http.DefaultTransport
+	if c.Transport != nil {
+		base = c.Transport
+	}
+	bt, ok := base.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("can't configure tls of custom transport %T", base)
+	}
+	tlsConfig, err := newTLSConfig(t)
+	if err != nil {
+		return nil, err
+	}
+	transport := bt.Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	tc := *c
+	tc.Transport = transport
+	return &tc, nil
+}
+
+// tlsVersions defines supported min versions of tls
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// newTLSConfig builds tls config from config of client
+func newTLSConfig(t *runtime.TLS) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if t.CA != "" {
+		pem, err := ioutil.ReadFile(t.CA)
+		if err != nil {
+			return nil, fmt.Errorf("can't read CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate is found in CA %v", t.CA)
+		}
+		config.RootCAs = pool
+	}
+	if t.Cert != "" || t.Key != "" {
+		cert, err := tls.LoadX509KeyPair(t.Cert, t.Key)
+		if err != nil {
+			return nil, fmt.Errorf("can't load client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if t.MinVersion != "" {
+		v, ok := tlsVersions[t.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unknown tls version %v", t.MinVersion)
+		}
+		config.MinVersion = v
+	}
+	return config, nil
+}
+
+// configuredClient returns client built from config of round trip
+// Clients are cached so that connections can be reused
+func (cs *clientset) configuredClient(name string, c *runtime.Client) (*http.Client, error) {
+	key, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := name + "/" + string(key)
+	if client, ok := cs.built[cacheKey]; ok {
+		return client, nil
+	}
+	client, err := NewClient(c)
+	if err != nil {
+		return nil, fmt.Errorf("can't build client %v: %v", name, err)
+	}
+	if c.CookieJar != "" {
+		// jar is only used by the client built from this config
+		jar, err := newCookieJar(CookieScope(c.CookieJar))
+		if err != nil {
+			return nil, err
+		}
+		cs.builtJars = append(cs.builtJars, jar)
+		client.Jar = jar
+	}
+	cs.built[cacheKey] = client
+	return client, nil
+}
@@ -0,0 +1,113 @@
+package roundtrip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/caicloud/aloe/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfiguredClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/login", http.StatusFound)
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/whoami":
+			if _, err := r.Cookie("session"); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	noRedirect := 0
+	clients := map[string]*runtime.Client{
+		"noRedirect": {MaxRedirects: &noRedirect},
+		"fast":       {Timeout: 50 * time.Millisecond},
+		"session":    {CookieJar: string(ContextScope)},
+	}
+	cs := NewClientset(nil)
+	do := func(client, path string) (int, error) {
+		resp, err := cs.DoRequest(&runtime.RoundTrip{
+			RoundTripTemplate: runtime.RoundTripTemplate{
+				Client:  client,
+				Clients: clients,
+				Request: runtime.Request{Host: u.Host, Method: http.MethodGet, Path: path},
+			},
+		})
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	code, err := do("noRedirect", "/redirect")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusFound, code)
+
+	code, err = do("", "/redirect")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	_, err = do("fast", "/slow")
+	assert.Error(t, err)
+
+	_, err = do("session", "/login")
+	require.NoError(t, err)
+	code, err = do("session", "/whoami")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	// jar is scoped to the config which enables it
+	clients["session"] = &runtime.Client{}
+	code, err = do("session", "/whoami")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	cs.ResetCookies(ContextScope)
+	clients["session"] = &runtime.Client{CookieJar: string(ContextScope)}
+	code, err = do("session", "/whoami")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	_, err = do("unknown", "/login")
+	assert.Error(t, err)
+}
+
+func TestWithTLS(t *testing.T) {
+	jar, err := newCookieJar(ContextScope)
+	require.NoError(t, err)
+	transport := &http.Transport{MaxIdleConnsPerHost: 7}
+	c := &http.Client{Transport: transport, Jar: jar, Timeout: time.Second}
+
+	tc, err := WithTLS(c, &runtime.TLS{ServerName: "products", MinVersion: "1.2"})
+	require.NoError(t, err)
+	tt, ok := tc.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, "products", tt.TLSClientConfig.ServerName)
+	assert.Equal(t, 7, tt.MaxIdleConnsPerHost)
+	assert.Equal(t, jar, tc.Jar)
+	assert.Equal(t, time.Second, tc.Timeout)
+	// client itself is not changed
+	assert.True(t, c.Transport == transport)
+	assert.True(t, transport.TLSClientConfig == nil || transport.TLSClientConfig.ServerName == "")
+
+	tc, err = WithTLS(&http.Client{}, &runtime.TLS{ServerName: "products"})
+	require.NoError(t, err)
+	require.IsType(t, &http.Transport{}, tc.Transport)
+
+	_, err = WithTLS(&http.Client{Transport: http.NewFileTransport(http.Dir("."))}, &runtime.TLS{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,138 @@
+package roundtrip
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// GzipEncoding defines gzip content encoding
+	GzipEncoding = "gzip"
+	// DeflateEncoding defines deflate content encoding
+	DeflateEncoding = "deflate"
+	// IdentityEncoding means body is not encoded
+	IdentityEncoding = "identity"
+)
+
+// acceptEncoding is sent if Accept-Encoding is not set by request
+const acceptEncoding = GzipEncoding + ", " + DeflateEncoding
+
+// compressBody compresses body with encoding
+func compressBody(body io.Reader, encoding string) (io.Reader, error) {
+	buf := &bytes.Buffer{}
+	var w io.WriteCloser
+	switch encoding {
+	case GzipEncoding:
+		w = gzip.NewWriter(buf)
+	case DeflateEncoding:
+		w = zlib.NewWriter(buf)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+	if body != nil {
+		if _, err := io.Copy(w, body); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// decompressResponse replaces body of response with decompressed one
+// Content-Encoding header is kept so that it can be checked
+// Responses which have no body, e.g. response of HEAD, are kept as they are
+func decompressResponse(resp *http.Response) {
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified ||
+		resp.ContentLength == 0 || (resp.Request != nil && resp.Request.Method == http.MethodHead) {
+		return
+	}
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	var newReader func(io.Reader) (io.Reader, error)
+	switch encoding {
+	case GzipEncoding, "x-gzip":
+		newReader = func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		}
+	case DeflateEncoding:
+		newReader = newDeflateReader
+	default:
+		return
+	}
+	resp.Body = &decompressedBody{
+		body:      resp.Body,
+		encoding:  encoding,
+		newReader: newReader,
+	}
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// newDeflateReader returns reader of deflate body
+// Deflate body should be in zlib format but some servers send raw deflate data
+func newDeflateReader(body io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(body)
+	header, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decompressedBody creates decompressor on the first read,
+// so that errors of compressed data are returned by reading
+type decompressedBody struct {
+	body      io.ReadCloser
+	encoding  string
+	newReader func(io.Reader) (io.Reader, error)
+
+	reader io.Reader
+	err    error
+}
+
+// Read implements io.Reader
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		br := bufio.NewReader(b.body)
+		if _, err := br.Peek(1); err == io.EOF {
+			// empty body is not compressed
+			b.reader = br
+		} else if r, err := b.newReader(br); err != nil {
+			b.err = fmt.Errorf("can't decompress %v body: %v", b.encoding, err)
+		} else {
+			b.reader = r
+		}
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.reader.Read(p)
+}
+
+// Close implements io.Closer
+func (b *decompressedBody) Close() error {
+	if c, ok := b.reader.(io.Closer); ok {
+		c.Close()
+	}
+	return b.body.Close()
+}
+
+// contentEncoding returns content encoding of response
+func contentEncoding(header http.Header) string {
+	encoding := strings.ToLower(strings.TrimSpace(header.Get("Content-Encoding")))
+	if encoding == "" {
+		return IdentityEncoding
+	}
+	return encoding
+}
@@ -0,0 +1,143 @@
+package roundtrip
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/caicloud/aloe/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoRequestWithCompression(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == GzipEncoding {
+			zr, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body = zr
+		}
+		content, err := ioutil.ReadAll(body)
+		require.NoError(t, err)
+
+		w.Header().Set("Content-Encoding", GzipEncoding)
+		zw := gzip.NewWriter(w)
+		_, err = zw.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	req := &runtime.Request{
+		Host:     u.Host,
+		Method:   http.MethodPost,
+		Path:     "/echo",
+		Body:     []byte(`{"id": "1"}`),
+		Compress: GzipEncoding,
+	}
+	resp, err := doRequest(http.DefaultClient, req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id": "1"}`, string(body))
+	assert.Equal(t, GzipEncoding, contentEncoding(resp.Header))
+}
+
+func TestDecompressResponse(t *testing.T) {
+	content := []byte(`{"id": "1"}`)
+	zlibBody := &bytes.Buffer{}
+	zw := zlib.NewWriter(zlibBody)
+	_, err := zw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	rawBody := &bytes.Buffer{}
+	fw, err := flate.NewWriter(rawBody, flate.DefaultCompression)
+	require.NoError(t, err)
+	_, err = fw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, fw.Close())
+
+	cases := []struct {
+		desc     string
+		encoding string
+		body     []byte
+	}{
+		{"zlib deflate", DeflateEncoding, zlibBody.Bytes()},
+		{"raw deflate", DeflateEncoding, rawBody.Bytes()},
+		{"identity", "", content},
+	}
+	for _, c := range cases {
+		resp := newResponse(200, "application/json", string(c.body))
+		resp.ContentLength = int64(len(c.body))
+		resp.Header.Set("Content-Encoding", c.encoding)
+		decompressResponse(resp)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err, c.desc)
+		assert.Equal(t, content, body, c.desc)
+	}
+
+	// invalid data is reported by reading body
+	resp := newResponse(200, "application/json", "not gzip")
+	resp.ContentLength = -1
+	resp.Header.Set("Content-Encoding", GzipEncoding)
+	decompressResponse(resp)
+	_, err = ioutil.ReadAll(resp.Body)
+	assert.Error(t, err)
+}
+
+func TestDoRequestWithEmptyCompressedBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", GzipEncoding)
+		switch r.URL.Path {
+		case "/no-content":
+			w.WriteHeader(http.StatusNoContent)
+		case "/not-modified":
+			w.WriteHeader(http.StatusNotModified)
+		case "/empty":
+			// body is chunked so that length is unknown
+			w.(http.Flusher).Flush()
+		default:
+			w.Header().Set("Content-Length", "20")
+		}
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	cases := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodHead, "/", http.StatusOK},
+		{http.MethodGet, "/no-content", http.StatusNoContent},
+		{http.MethodGet, "/not-modified", http.StatusNotModified},
+		{http.MethodGet, "/empty", http.StatusOK},
+	}
+	for _, c := range cases {
+		resp, err := doRequest(http.DefaultClient, &runtime.Request{
+			Host:   u.Host,
+			Method: c.method,
+			Path:   c.path,
+		})
+		require.NoError(t, err, c.path)
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err, c.method+" "+c.path)
+		assert.Empty(t, body, c.path)
+		assert.Equal(t, c.code, resp.StatusCode, c.path)
+	}
+}
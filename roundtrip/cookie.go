@@ -0,0 +1,111 @@
+package roundtrip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/caicloud/aloe/matcher"
+	gomegatypes "github.com/onsi/gomega/types"
+)
+
+// cookieMatcher matches cookies set by response
+type cookieMatcher struct {
+	matcher gomegatypes.GomegaMatcher
+}
+
+// newCookieMatcher returns a matcher of cookies set by response
+// Value of cookie is a json matcher if it is a json object,
+// otherwise it matches value of cookie
+func newCookieMatcher(cookies map[string]string) (*cookieMatcher, error) {
+	expected := map[string]interface{}{}
+	for name, v := range cookies {
+		expected[name] = cookieExpr(v)
+	}
+	body, err := json.Marshal(expected)
+	if err != nil {
+		return nil, err
+	}
+	m, err := parseMatcher(body)
+	if err != nil {
+		return nil, fmt.Errorf("parse cookie matcher error: %v", err)
+	}
+	return &cookieMatcher{
+		matcher: m,
+	}, nil
+}
+
+// cookieExpr returns matcher expression of a cookie
+// An object with attributes, e.g. {"value": "a", "httpOnly": true},
+// and {"$exists": false} match the cookie, others match value of cookie
+func cookieExpr(v string) interface{} {
+	trimmed := strings.TrimSpace(v)
+	if !strings.HasPrefix(trimmed, "{") {
+		return map[string]interface{}{"value": v}
+	}
+	expr := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(trimmed), &expr); err != nil {
+		return map[string]interface{}{"value": v}
+	}
+	if _, ok := expr[matcher.ExistsMatcher]; ok && len(expr) == 1 {
+		return expr
+	}
+	for k := range expr {
+		if !strings.HasPrefix(k, "$") {
+			return expr
+		}
+	}
+	return map[string]interface{}{"value": expr}
+}
+
+// cookieObject converts cookie to an object which can be matched
+func cookieObject(c *http.Cookie) map[string]interface{} {
+	obj := map[string]interface{}{
+		"value":    c.Value,
+		"path":     c.Path,
+		"domain":   c.Domain,
+		"maxAge":   c.MaxAge,
+		"secure":   c.Secure,
+		"httpOnly": c.HttpOnly,
+		"sameSite": sameSite(c.SameSite),
+	}
+	if !c.Expires.IsZero() {
+		obj["expires"] = c.Expires.UTC().Format(time.RFC3339)
+	}
+	return obj
+}
+
+func sameSite(s http.SameSite) string {
+	switch s {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return ""
+}
+
+// match returns failures of cookies
+func (cm *cookieMatcher) match(resp *http.Response) []string {
+	actual := map[string]interface{}{}
+	for _, c := range resp.Cookies() {
+		// the last one wins if a cookie is set twice
+		actual[c.Name] = cookieObject(c)
+	}
+	return matchObject(cm.matcher, actual)
+}
+
+// cookieValue returns value of cookie set by response
+func cookieValue(resp *http.Response, name string) (string, bool) {
+	value, found := "", false
+	for _, c := range resp.Cookies() {
+		if c.Name == name {
+			value, found = c.Value, true
+		}
+	}
+	return value, found
+}
@@ -0,0 +1,133 @@
+package roundtrip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/caicloud/aloe/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCookieMatcher(t *testing.T) {
+	cases := []struct {
+		desc     string
+		expected map[string]string
+		failures int
+	}{
+		{
+			"value",
+			map[string]string{"session": "abc"},
+			0,
+		},
+		{
+			"wrong value",
+			map[string]string{"session": "def"},
+			1,
+		},
+		{
+			"value matcher",
+			map[string]string{"session": `{"$regexp": "^a"}`},
+			0,
+		},
+		{
+			"attributes",
+			map[string]string{"session": `{"value": "abc", "httpOnly": true, "secure": true, "sameSite": "Lax", "maxAge": 3600}`},
+			0,
+		},
+		{
+			"expiry",
+			map[string]string{"session": `{"expires": {"$time": {"after": "2030-01-01T00:00:00Z"}}}`},
+			0,
+		},
+		{
+			"wrong attributes",
+			map[string]string{"session": `{"httpOnly": false, "sameSite": "Strict"}`},
+			2,
+		},
+		{
+			"not exist",
+			map[string]string{"token": `{"$exists": false}`},
+			0,
+		},
+		{
+			"missing",
+			map[string]string{"token": "abc"},
+			1,
+		},
+	}
+	resp := newResponse(200, "", "")
+	resp.Header.Add("Set-Cookie", "session=abc; Path=/; Expires=Sun, 01 Jan 2040 00:00:00 GMT; Max-Age=3600; HttpOnly; Secure; SameSite=Lax")
+	for _, c := range cases {
+		cm, err := newCookieMatcher(c.expected)
+		require.NoError(t, err, c.desc)
+		failures := cm.match(resp)
+		assert.Len(t, failures, c.failures, "%v: %v", c.desc, failures)
+	}
+}
+
+func TestCookieJar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		case "/whoami":
+			c, err := r.Cookie("session")
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(c.Value))
+		}
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	cs := NewClientset(nil)
+	assert.Error(t, cs.EnableCookieJar("", "suite"))
+	require.NoError(t, cs.EnableCookieJar("", CaseScope))
+
+	do := func(path string) int {
+		resp, err := cs.DoRequest(&runtime.RoundTrip{
+			RoundTripTemplate: runtime.RoundTripTemplate{
+				Request: runtime.Request{Host: u.Host, Method: http.MethodGet, Path: path},
+			},
+		})
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusUnauthorized, do("/whoami"))
+	assert.Equal(t, http.StatusOK, do("/login"))
+	assert.Equal(t, http.StatusOK, do("/whoami"))
+
+	cs.ResetCookies(ContextScope)
+	assert.Equal(t, http.StatusOK, do("/whoami"))
+	cs.ResetCookies(CaseScope)
+	assert.Equal(t, http.StatusUnauthorized, do("/whoami"))
+}
+
+func TestCookieDefinition(t *testing.T) {
+	m, err := MatchResponse(&runtime.RoundTrip{
+		Definitions: []runtime.Definition{
+			{
+				Var:  runtime.Var{Name: "session", Selector: []string{"session"}},
+				Type: runtime.CookieType,
+			},
+		},
+	})
+	require.NoError(t, err)
+	resp := newResponse(200, "", "")
+	resp.Header.Add("Set-Cookie", "session=abc; Path=/")
+	matched, err := m.Match(resp)
+	require.NoError(t, err)
+	require.True(t, matched)
+	assert.Equal(t, "abc", m.Variables()["session"].String())
+
+	matched, err = m.Match(newResponse(200, "", ""))
+	require.NoError(t, err)
+	assert.False(t, matched)
+}
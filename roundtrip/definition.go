@@ -0,0 +1,173 @@
+package roundtrip
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/caicloud/aloe/runtime"
+	"github.com/caicloud/aloe/utils/jsonpath"
+	"github.com/caicloud/aloe/utils/jsonutil"
+)
+
+// define returns variable defined from response
+// body is the raw body and data is body in json
+func define(def *runtime.Definition, resp *http.Response, body, data []byte) (jsonutil.Variable, error) {
+	switch def.Type {
+	case runtime.BodyType:
+		if def.Regexp != nil && len(def.Selector) == 0 {
+			return extract(def, string(body))
+		}
+		return selectVariable(def, data)
+	case runtime.HeadersType:
+		return selectObject(def, headerObject(resp.Header))
+	case runtime.ResponseType:
+		return selectObject(def, responseObject(resp))
+	case runtime.RedirectsType:
+		return selectObject(def, redirectChain(resp))
+	case runtime.TLSType:
+		if resp.TLS == nil {
+			return nil, fmt.Errorf("connection is not secure")
+		}
+		return selectObject(def, tlsObject(resp.TLS))
+	case runtime.StatusType:
+		if def.Regexp == nil {
+			return jsonutil.NewIntVariable(def.Name, int64(resp.StatusCode)), nil
+		}
+		return extract(def, strconv.Itoa(resp.StatusCode))
+	case runtime.HeaderType:
+		if len(def.Selector) != 1 {
+			return nil, fmt.Errorf("header definition expected selector with len 1, actual is %v", def.Selector)
+		}
+		value := resp.Header.Get(def.Selector[0])
+		if def.Regexp == nil {
+			return jsonutil.NewStringVariable(def.Name, value), nil
+		}
+		return extract(def, value)
+	case runtime.CookieType:
+		if len(def.Selector) != 1 {
+			return nil, fmt.Errorf("cookie definition expected selector with len 1, actual is %v", def.Selector)
+		}
+		value, ok := cookieValue(resp, def.Selector[0])
+		if def.Regexp != nil {
+			return extract(def, value)
+		}
+		if !ok {
+			return nil, fmt.Errorf("cookie %v is not set by response", def.Selector[0])
+		}
+		return jsonutil.NewStringVariable(def.Name, value), nil
+	}
+	return nil, fmt.Errorf("unknown definition type %v", def.Type)
+}
+
+// selectObject selects variable from object by selector of definition
+func selectObject(def *runtime.Definition, obj interface{}) (jsonutil.Variable, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return selectVariable(def, data)
+}
+
+// selectVariable selects variable from json by selector of definition
+// regexp is applied to the selected value if it is defined
+func selectVariable(def *runtime.Definition, data []byte) (jsonutil.Variable, error) {
+	var v jsonutil.Variable
+	var err error
+	if len(def.Selector) == 1 && jsonpath.IsJSONPath(def.Selector[0]) {
+		v, err = jsonutil.GetVariableByPath(data, def.Name, def.Selector[0])
+	} else {
+		v, err = jsonutil.GetVariable(data, def.Name, def.Selector...)
+	}
+	if err != nil || def.Regexp == nil {
+		return v, err
+	}
+	return extract(def, v.String())
+}
+
+// headerObject converts header to an object
+// Values of each header are in an array
+func headerObject(h http.Header) map[string]interface{} {
+	obj := map[string]interface{}{}
+	for k, vs := range h {
+		values := make([]interface{}, 0, len(vs))
+		for _, v := range vs {
+			values = append(values, v)
+		}
+		obj[http.CanonicalHeaderKey(k)] = values
+	}
+	return obj
+}
+
+// responseObject converts info of response to an object
+func responseObject(resp *http.Response) map[string]interface{} {
+	return map[string]interface{}{
+		"statusCode":    resp.StatusCode,
+		"proto":         resp.Proto,
+		"contentLength": resp.ContentLength,
+		"uncompressed":  resp.Uncompressed,
+		"headers":       headerObject(resp.Header),
+		"tls":           tlsObject(resp.TLS),
+		"redirects":     redirectChain(resp),
+	}
+}
+
+// tlsObject converts tls connection state to an object
+// It returns nil if connection is not secure
+func tlsObject(state *tls.ConnectionState) map[string]interface{} {
+	if state == nil {
+		return nil
+	}
+	certs := make([]interface{}, 0, len(state.PeerCertificates))
+	for _, cert := range state.PeerCertificates {
+		dnsNames := make([]interface{}, 0, len(cert.DNSNames))
+		for _, name := range cert.DNSNames {
+			dnsNames = append(dnsNames, name)
+		}
+		ips := make([]interface{}, 0, len(cert.IPAddresses))
+		for _, ip := range cert.IPAddresses {
+			ips = append(ips, ip.String())
+		}
+		certs = append(certs, map[string]interface{}{
+			"subject":     cert.Subject.String(),
+			"issuer":      cert.Issuer.String(),
+			"notBefore":   cert.NotBefore.UTC().Format(time.RFC3339),
+			"notAfter":    cert.NotAfter.UTC().Format(time.RFC3339),
+			"dnsNames":    dnsNames,
+			"ipAddresses": ips,
+		})
+	}
+	return map[string]interface{}{
+		"version":            tls.VersionName(state.Version),
+		"cipherSuite":        tls.CipherSuiteName(state.CipherSuite),
+		"serverName":         state.ServerName,
+		"negotiatedProtocol": state.NegotiatedProtocol,
+		"peerCertificates":   certs,
+	}
+}
+
+// extract applies regexp of definition to value
+// The first capture group is returned, or whole match if there is no group
+// Default is returned if regexp captures nothing
+func extract(def *runtime.Definition, value string) (jsonutil.Variable, error) {
+	captured, ok := "", false
+	if match := def.Regexp.FindStringSubmatchIndex(value); match != nil {
+		group := 0
+		if len(match) > 2 {
+			group = 1
+		}
+		if match[2*group] >= 0 {
+			captured, ok = value[match[2*group]:match[2*group+1]], true
+		}
+	}
+	if def.Default != nil && captured == "" {
+		return jsonutil.NewStringVariable(def.Name, *def.Default), nil
+	}
+	if !ok {
+		return nil, fmt.Errorf("%q is not matched by regexp %v", truncate(value), def.Regexp)
+	}
+	return jsonutil.NewStringVariable(def.Name, captured), nil
+}
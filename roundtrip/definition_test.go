@@ -0,0 +1,180 @@
+package roundtrip
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/caicloud/aloe/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegexpDefinition(t *testing.T) {
+	none := "none"
+	cases := []struct {
+		desc     string
+		def      runtime.Definition
+		expected string
+		hasError bool
+	}{
+		{
+			"header",
+			runtime.Definition{
+				Var:    runtime.Var{Selector: []string{"Location"}},
+				Type:   runtime.HeaderType,
+				Regexp: regexp.MustCompile(`^/products/(\w+)$`),
+			},
+			"abc123",
+			false,
+		},
+		{
+			"raw body",
+			runtime.Definition{
+				Type:   runtime.BodyType,
+				Regexp: regexp.MustCompile(`<span id="name">(.*?)</span>`),
+			},
+			"foo",
+			false,
+		},
+		{
+			"whole match",
+			runtime.Definition{
+				Type:   runtime.StatusType,
+				Regexp: regexp.MustCompile(`^\d`),
+			},
+			"2",
+			false,
+		},
+		{
+			"optional capture with default",
+			runtime.Definition{
+				Var:     runtime.Var{Selector: []string{"Location"}},
+				Type:    runtime.HeaderType,
+				Regexp:  regexp.MustCompile(`\?version=(\d+)|$`),
+				Default: &none,
+			},
+			"none",
+			false,
+		},
+		{
+			"not matched with default",
+			runtime.Definition{
+				Type:    runtime.BodyType,
+				Regexp:  regexp.MustCompile(`id: (\d+)`),
+				Default: &none,
+			},
+			"none",
+			false,
+		},
+		{
+			"not matched",
+			runtime.Definition{
+				Type:   runtime.BodyType,
+				Regexp: regexp.MustCompile(`id: (\d+)`),
+			},
+			"",
+			true,
+		},
+	}
+	resp := newResponse(201, "text/html", "")
+	resp.Header.Set("Location", "/products/abc123")
+	body := []byte(`<html><span id="name">foo</span></html>`)
+	for _, c := range cases {
+		c.def.Name = "v"
+		v, err := define(&c.def, resp, body, nil)
+		if c.hasError {
+			assert.Error(t, err, c.desc)
+			continue
+		}
+		require.NoError(t, err, c.desc)
+		assert.Equal(t, c.expected, v.String(), c.desc)
+	}
+}
+
+func TestMatchResponseWithRegexpDefinition(t *testing.T) {
+	m, err := MatchResponse(&runtime.RoundTrip{
+		Definitions: []runtime.Definition{
+			{
+				Var:    runtime.Var{Name: "name", Selector: []string{"name"}},
+				Type:   runtime.BodyType,
+				Regexp: regexp.MustCompile(`^(\w+)-`),
+			},
+			{
+				Var:    runtime.Var{Name: "token"},
+				Type:   runtime.BodyType,
+				Regexp: regexp.MustCompile(`"token": "(\w+)"`),
+			},
+		},
+	})
+	require.NoError(t, err)
+	matched, err := m.Match(newResponse(200, "application/json", `{"name": "foo-1", "token": "abc"}`))
+	require.NoError(t, err)
+	require.True(t, matched, m.FailureMessage(nil))
+	assert.Equal(t, "foo", m.Variables()["name"].String())
+	assert.Equal(t, "abc", m.Variables()["token"].String())
+}
+
+func TestHeadersAndResponseDefinition(t *testing.T) {
+	cases := []struct {
+		desc     string
+		def      runtime.Definition
+		expected string
+	}{
+		{
+			"header map",
+			runtime.Definition{Type: runtime.HeadersType},
+			`{"Content-Type":["application/json"],"Vary":["Accept","Cookie"]}`,
+		},
+		{
+			"all values of header",
+			runtime.Definition{
+				Var:  runtime.Var{Selector: []string{"Vary"}},
+				Type: runtime.HeadersType,
+			},
+			`["Accept","Cookie"]`,
+		},
+		{
+			"jsonpath of header",
+			runtime.Definition{
+				Var:  runtime.Var{Selector: []string{"$.Vary[1]"}},
+				Type: runtime.HeadersType,
+			},
+			"Cookie",
+		},
+		{
+			"content length",
+			runtime.Definition{
+				Var:  runtime.Var{Selector: []string{"contentLength"}},
+				Type: runtime.ResponseType,
+			},
+			"11",
+		},
+		{
+			"proto",
+			runtime.Definition{
+				Var:  runtime.Var{Selector: []string{"proto"}},
+				Type: runtime.ResponseType,
+			},
+			"HTTP/1.1",
+		},
+		{
+			"no tls",
+			runtime.Definition{
+				Var:  runtime.Var{Selector: []string{"tls"}},
+				Type: runtime.ResponseType,
+			},
+			"null",
+		},
+	}
+	resp := newResponse(200, "application/json", `{"id": "1"}`)
+	resp.Header.Add("Vary", "Accept")
+	resp.Header.Add("Vary", "Cookie")
+	resp.Proto = "HTTP/1.1"
+	resp.ContentLength = 11
+	for _, c := range cases {
+		c.def.Name = "v"
+		v, err := define(&c.def, resp, nil, nil)
+		require.NoError(t, err, c.desc)
+		assert.Equal(t, c.expected, v.String(), c.desc)
+	}
+}
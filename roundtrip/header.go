@@ -0,0 +1,136 @@
+package roundtrip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/caicloud/aloe/matcher"
+	gomegatypes "github.com/onsi/gomega/types"
+)
+
+// multiValueMatchers defines matchers which match all values of a header
+var multiValueMatchers = []string{
+	matcher.ContainsMatcher,
+	matcher.UnorderedMatcher,
+	matcher.EachMatcher,
+	matcher.AnyMatcher,
+}
+
+// headerMatcher matches http header by matchers of body
+type headerMatcher struct {
+	matcher gomegatypes.GomegaMatcher
+
+	// multi defines headers whose values are all matched
+	multi map[string]bool
+}
+
+// newHeaderMatcher returns a matcher of headers
+// Value of header is a json matcher if it is a json object of special
+// matchers, e.g. {"$regexp": "^a"}, otherwise it is a string
+func newHeaderMatcher(headers map[string]string) (*headerMatcher, error) {
+	expected := map[string]interface{}{}
+	hm := &headerMatcher{
+		multi: map[string]bool{},
+	}
+	for k, v := range headers {
+		key := http.CanonicalHeaderKey(k)
+		if _, ok := expected[key]; ok {
+			return nil, fmt.Errorf("header %v is defined twice", key)
+		}
+		expr := headerExpr(v)
+		expected[key] = expr
+		hm.multi[key] = isMultiValue(expr)
+	}
+	body, err := json.Marshal(expected)
+	if err != nil {
+		return nil, err
+	}
+	m, err := parseMatcher(body)
+	if err != nil {
+		return nil, fmt.Errorf("parse header matcher error: %v", err)
+	}
+	hm.matcher = m
+	return hm, nil
+}
+
+func headerExpr(v string) interface{} {
+	trimmed := strings.TrimSpace(v)
+	if strings.HasPrefix(trimmed, "{") {
+		expr := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(trimmed), &expr); err == nil && hasSpecialKey(expr) {
+			return expr
+		}
+	}
+	if v == "" {
+		// empty header is same as header which doesn't exist
+		return map[string]interface{}{
+			matcher.OrMatcher: []interface{}{
+				map[string]interface{}{
+					matcher.ExistsMatcher: false,
+				},
+				"",
+			},
+		}
+	}
+	return v
+}
+
+// hasSpecialKey returns true if object has a special matcher
+func hasSpecialKey(obj map[string]interface{}) bool {
+	for k := range obj {
+		if strings.HasPrefix(k, "$") {
+			return true
+		}
+	}
+	return false
+}
+
+func isMultiValue(expr interface{}) bool {
+	e, ok := expr.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, k := range multiValueMatchers {
+		if _, ok := e[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns failures of header
+func (hm *headerMatcher) match(h http.Header) []string {
+	actual := map[string]interface{}{}
+	for k := range hm.multi {
+		values, ok := h[k]
+		if !ok {
+			continue
+		}
+		if hm.multi[k] {
+			vs := make([]interface{}, 0, len(values))
+			for _, v := range values {
+				vs = append(vs, v)
+			}
+			actual[k] = vs
+			continue
+		}
+		actual[k] = ""
+		if len(values) > 0 {
+			actual[k] = values[0]
+		}
+	}
+	matched, err := hm.matcher.Match(actual)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if matched {
+		return nil
+	}
+	failures := []string{}
+	for _, f := range matcher.Flatten(hm.matcher, actual) {
+		failures = append(failures, f.String())
+	}
+	return failures
+}
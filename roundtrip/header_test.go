@@ -0,0 +1,87 @@
+package roundtrip
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderMatcher(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("X-Request-Id", "abc-123")
+	header.Add("Set-Cookie", "session=1; HttpOnly")
+	header.Add("Set-Cookie", "theme=dark")
+	header.Add("Vary", "Accept")
+	header.Add("Vary", "Origin")
+	header.Set("X-Json", `{"a": 1}`)
+	header.Set("X-List", `["a"]`)
+
+	cases := []struct {
+		desc     string
+		headers  map[string]string
+		failures int
+	}{
+		{
+			"string value",
+			map[string]string{"content-type": "application/json"},
+			0,
+		},
+		{
+			"string value not matched",
+			map[string]string{"Content-Type": "text/plain"},
+			1,
+		},
+		{
+			"regexp",
+			map[string]string{"X-Request-Id": `{"$regexp": "^[a-z]+-[0-9]+$"}`},
+			0,
+		},
+		{
+			"not exists",
+			map[string]string{"X-Debug": `{"$exists": false}`, "X-Request-Id": `{"$exists": false}`},
+			1,
+		},
+		{
+			"empty value means not exists",
+			map[string]string{"X-Debug": ""},
+			0,
+		},
+		{
+			"all values",
+			map[string]string{"Vary": `{"$unordered": ["Origin", "Accept"]}`},
+			0,
+		},
+		{
+			"json values without special matchers are strings",
+			map[string]string{"X-Json": `{"a": 1}`, "X-List": `["a"]`},
+			0,
+		},
+		{
+			"contains values",
+			map[string]string{"Set-Cookie": `{"$contains": [{"$regexp": "^theme="}]}`},
+			0,
+		},
+		{
+			"each value",
+			map[string]string{"Set-Cookie": `{"$each": {"$regexp": "HttpOnly"}}`},
+			1,
+		},
+		{
+			"first value",
+			map[string]string{"Vary": "Accept"},
+			0,
+		},
+	}
+
+	for _, c := range cases {
+		hm, err := newHeaderMatcher(c.headers)
+		require.NoError(t, err, c.desc)
+		assert.Len(t, hm.match(header), c.failures, c.desc)
+	}
+
+	_, err := newHeaderMatcher(map[string]string{"vary": "a", "Vary": "b"})
+	assert.Error(t, err, "header defined twice")
+}
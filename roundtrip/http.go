@@ -2,9 +2,18 @@ package roundtrip
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/http/cookiejar"
+	"net/textproto"
+	"os"
+	"sort"
+	"strings"
 
 	"github.com/caicloud/aloe/runtime"
 )
@@ -18,12 +27,73 @@ type Clientset interface {
 	Set(name string, c *http.Client)
 	// Get get custom client with name
 	Get(name string) (*http.Client, bool)
+	// EnableCookieJar enables cookie jar of client with name
+	// Cookies in the jar will be reset by ResetCookies with the scope
+	EnableCookieJar(name string, scope CookieScope) error
+	// ResetCookies removes all cookies in jars with the scope
+	ResetCookies(scope CookieScope)
+	// Serve serves requests to host by handler in process
+	// Host is matched by hostname, so a port in host is ignored
+	// Requests of https scheme are served with a certificate
+	// signed by an auto-generated CA
+	Serve(host string, h http.Handler) error
+	// Close stops all servers started by Serve
+	Close()
 }
 
+// CookieScope defines when cookies in a jar are reset
+type CookieScope string
+
+const (
+	// ContextScope means cookies set in contexts are carried to
+	// children contexts and cases, cookies set in a case will not
+	// be carried to other cases
+	ContextScope CookieScope = "context"
+	// CaseScope means cookies are only carried in a case
+	CaseScope CookieScope = "case"
+)
+
 // clientset defines multiple clients
 type clientset struct {
 	defaultClient *http.Client
 	namedClients  map[string]*http.Client
+	jars          map[string]*cookieJar
+
+	// built defines clients built from configs in contexts
+	built map[string]*http.Client
+
+	// builtJars defines cookie jars of built clients
+	builtJars []*cookieJar
+
+	// transports defines clients with dedicated transports
+	// for unix and h2c schemes and served hosts
+	transports map[transportKey]*http.Client
+
+	// served defines handlers served in process by host
+	served map[string]*servedHandler
+}
+
+// cookieJar defines cookie jar of a client
+type cookieJar struct {
+	http.CookieJar
+	scope CookieScope
+}
+
+// newCookieJar returns an empty cookie jar with scope
+func newCookieJar(scope CookieScope) (*cookieJar, error) {
+	switch scope {
+	case ContextScope, CaseScope:
+	default:
+		return nil, fmt.Errorf("unknown cookie scope %q: only [context, case] is allowed", scope)
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &cookieJar{
+		CookieJar: jar,
+		scope:     scope,
+	}, nil
 }
 
 // NewClientset returns a clientset for roundtrip
@@ -31,6 +101,10 @@ func NewClientset(c *http.Client) Clientset {
 	cs := &clientset{
 		defaultClient: c,
 		namedClients:  map[string]*http.Client{},
+		jars:          map[string]*cookieJar{},
+		built:         map[string]*http.Client{},
+		transports:    map[transportKey]*http.Client{},
+		served:        map[string]*servedHandler{},
 	}
 	if cs.defaultClient == nil {
 		cs.defaultClient = http.DefaultClient
@@ -56,36 +130,246 @@ func (cs *clientset) Get(name string) (*http.Client, bool) {
 	return c, ok
 }
 
+// EnableCookieJar implements Clientset interface
+func (cs *clientset) EnableCookieJar(name string, scope CookieScope) error {
+	jar, err := newCookieJar(scope)
+	if err != nil {
+		return err
+	}
+	cs.jars[name] = jar
+	return nil
+}
+
+// ResetCookies implements Clientset interface
+func (cs *clientset) ResetCookies(scope CookieScope) {
+	jars := append([]*cookieJar{}, cs.builtJars...)
+	for _, jar := range cs.jars {
+		jars = append(jars, jar)
+	}
+	for _, jar := range jars {
+		if jar.scope != scope {
+			continue
+		}
+		// cookiejar.Jar can't be cleared, so replace it with a new one
+		if newJar, err := cookiejar.New(nil); err == nil {
+			jar.CookieJar = newJar
+		}
+	}
+}
+
 // DoRequest implements Clientset interface
 func (cs *clientset) DoRequest(rt *runtime.RoundTrip) (*http.Response, error) {
 	c, ok := cs.Get(rt.Client)
+	jar, hasJar := cs.jars[rt.Client]
+	// clients defined in contexts override clients customized in framework
+	if conf, configured := rt.Clients[rt.Client]; configured {
+		built, err := cs.configuredClient(rt.Client, conf)
+		if err != nil {
+			return nil, err
+		}
+		c, ok = built, true
+		// cookie jar enabled by client config takes precedence
+		hasJar = hasJar && built.Jar == nil
+	}
 	if !ok {
 		return nil, fmt.Errorf("can't find client with name %s", rt.Client)
 	}
+	c, err := cs.withTransport(c, &rt.Request)
+	if err != nil {
+		return nil, err
+	}
+	if hasJar {
+		withJar := *c
+		withJar.Jar = jar.CookieJar
+		c = &withJar
+	}
 	return doRequest(c, &rt.Request)
 }
 
 func doRequest(c *http.Client, reqConf *runtime.Request) (*http.Response, error) {
-	var body io.Reader
-	if reqConf.Body != nil {
-		body = bytes.NewBuffer(reqConf.Body)
+	body, contentType, err := requestBody(reqConf)
+	if err != nil {
+		return nil, err
+	}
+	if reqConf.Compress != "" {
+		if body, err = compressBody(body, reqConf.Compress); err != nil {
+			return nil, err
+		}
 	}
 
-	req, err := http.NewRequest(reqConf.Method, getURL(reqConf), body)
+	ctx, cancel := requestContext(reqConf)
+	req, err := http.NewRequestWithContext(ctx, reqConf.Method, getURL(reqConf), body)
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 	for k, v := range reqConf.Headers {
 		req.Header.Set(k, v)
 	}
+	names := make([]string, 0, len(reqConf.Cookies))
+	for name := range reqConf.Cookies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		req.AddCookie(&http.Cookie{
+			Name:  name,
+			Value: reqConf.Cookies[name],
+		})
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if reqConf.Compress != "" {
+		req.Header.Set("Content-Encoding", reqConf.Compress)
+	}
+	// set Accept-Encoding explicitly so that http.Transport will not
+	// decompress body and remove Content-Encoding header silently
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	if reqConf.MaxRedirects != nil {
+		withPolicy := *c
+		withPolicy.CheckRedirect = checkRedirect(*reqConf.MaxRedirects)
+		c = &withPolicy
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// context is canceled after body is closed
+	resp.Body = &cancelBody{
+		ReadCloser: resp.Body,
+		cancel:     cancel,
+	}
+	decompressResponse(resp)
+	return resp, nil
+}
 
-	return c.Do(req)
+// requestContext returns context of request with timeout and deadline
+func requestContext(reqConf *runtime.Request) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancels := []context.CancelFunc{cancel}
+	if reqConf.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, reqConf.Timeout)
+		cancels = append(cancels, cancel)
+	}
+	if !reqConf.Deadline.IsZero() {
+		ctx, cancel = context.WithDeadline(ctx, reqConf.Deadline)
+		cancels = append(cancels, cancel)
+	}
+	return ctx, func() {
+		for _, c := range cancels {
+			c()
+		}
+	}
+}
+
+// cancelBody cancels context of request when it is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// IsTimeout returns true if err is caused by timeout
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+// requestBody returns body of request and the content type which should be set
+// Content type is empty if body is defined as raw bytes
+func requestBody(reqConf *runtime.Request) (io.Reader, string, error) {
+	switch {
+	case reqConf.Multipart != nil:
+		return multipartBody(reqConf.Multipart)
+	case reqConf.Form != nil:
+		return strings.NewReader(reqConf.Form.Encode()), "application/x-www-form-urlencoded", nil
+	case reqConf.Body != nil:
+		return bytes.NewBuffer(reqConf.Body), "", nil
+	}
+	return nil, "", nil
+}
+
+func multipartBody(mp *runtime.Multipart) (io.Reader, string, error) {
+	buf := bytes.Buffer{}
+	w := multipart.NewWriter(&buf)
+
+	keys := make([]string, 0, len(mp.Fields))
+	for k := range mp.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range mp.Fields[k] {
+			if err := w.WriteField(k, v); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	for _, f := range mp.Files {
+		if err := writeFilePart(w, &f); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return &buf, w.FormDataContentType(), nil
+}
+
+func writeFilePart(w *multipart.Writer, f *runtime.MultipartFile) error {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return fmt.Errorf("can't open file of multipart field %v: %v", f.Name, err)
+	}
+	defer file.Close()
+
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+		"name":     f.Name,
+		"filename": f.Filename,
+	}))
+	h.Set("Content-Type", f.ContentType)
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, file)
+	return err
 }
 
 func getURL(req *runtime.Request) string {
 	scheme := "http://"
-	if req.Scheme != "" {
-		scheme = req.Scheme + "://"
+	host := req.Host
+	switch s, _ := dialTarget(req); s {
+	case "":
+	case UnixScheme:
+		// host is ignored because connection is dialed to the socket
+		host = "localhost"
+	case H2CScheme:
+	default:
+		scheme = s + "://"
+	}
+	u := scheme + host + req.Path
+	if len(req.Query) == 0 {
+		return u
+	}
+	if strings.Contains(req.Path, "?") {
+		return u + "&" + req.Query.Encode()
 	}
-	return scheme + req.Host + req.Path
+	return u + "?" + req.Query.Encode()
 }
@@ -0,0 +1,149 @@
+package roundtrip
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caicloud/aloe/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoRequestWithForm(t *testing.T) {
+	var received *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		received = r
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	req := &runtime.Request{
+		Host:   u.Host,
+		Method: http.MethodPost,
+		Path:   "/login",
+		Form: url.Values{
+			"user":  []string{"admin"},
+			"roles": []string{"a", "b"},
+		},
+	}
+	resp, err := doRequest(http.DefaultClient, req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "application/x-www-form-urlencoded", received.Header.Get("Content-Type"))
+	assert.Equal(t, req.Form, received.PostForm)
+}
+
+func TestDoRequestWithMultipart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "multipart")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logo.png")
+	require.NoError(t, ioutil.WriteFile(path, []byte("fake png"), 0666))
+
+	type part struct {
+		filename    string
+		contentType string
+		content     string
+	}
+	var fields url.Values
+	files := map[string]part{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		fields = r.MultipartForm.Value
+		for name, fhs := range r.MultipartForm.File {
+			f, err := fhs[0].Open()
+			require.NoError(t, err)
+			content, err := ioutil.ReadAll(f)
+			require.NoError(t, err)
+			files[name] = part{
+				filename:    fhs[0].Filename,
+				contentType: fhs[0].Header.Get("Content-Type"),
+				content:     string(content),
+			}
+		}
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	req := &runtime.Request{
+		Host:   u.Host,
+		Method: http.MethodPost,
+		Path:   "/upload",
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Multipart: &runtime.Multipart{
+			Fields: url.Values{
+				"description": []string{"logo"},
+			},
+			Files: []runtime.MultipartFile{
+				{
+					Name:        "image",
+					Path:        path,
+					Filename:    "logo.png",
+					ContentType: "image/png",
+				},
+			},
+		},
+	}
+	resp, err := doRequest(http.DefaultClient, req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, req.Multipart.Fields, fields)
+	assert.Equal(t, map[string]part{
+		"image": {"logo.png", "image/png", "fake png"},
+	}, files)
+
+	req.Multipart.Files[0].Path = filepath.Join(dir, "missing.png")
+	_, err = doRequest(http.DefaultClient, req)
+	assert.Error(t, err)
+}
+
+func TestGetURL(t *testing.T) {
+	cases := []struct {
+		desc     string
+		req      runtime.Request
+		expected string
+	}{
+		{
+			"without query",
+			runtime.Request{Host: "localhost", Path: "/products"},
+			"http://localhost/products",
+		},
+		{
+			"with query",
+			runtime.Request{
+				Scheme: "https",
+				Host:   "localhost",
+				Path:   "/products",
+				Query: url.Values{
+					"apiVersion": []string{"v1"},
+					"name":       []string{"a b", "c&d"},
+				},
+			},
+			"https://localhost/products?apiVersion=v1&name=a+b&name=c%26d",
+		},
+		{
+			"with query in path",
+			runtime.Request{
+				Host:  "localhost",
+				Path:  "/products?limit=10",
+				Query: url.Values{"start": []string{"1"}},
+			},
+			"http://localhost/products?limit=10&start=1",
+		},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, getURL(&c.req), c.desc)
+	}
+}
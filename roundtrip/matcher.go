@@ -2,22 +2,32 @@ package roundtrip
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"reflect"
 	"strings"
 
+	"github.com/caicloud/aloe/codec"
 	"github.com/caicloud/aloe/matcher"
 	"github.com/caicloud/aloe/runtime"
 	"github.com/caicloud/aloe/utils/close"
+	"github.com/caicloud/aloe/utils/diff"
 	"github.com/caicloud/aloe/utils/indent"
 	"github.com/caicloud/aloe/utils/jsonutil"
+	"github.com/onsi/ginkgo/config"
 	"github.com/onsi/gomega/format"
 	gomegatypes "github.com/onsi/gomega/types"
 )
 
+const (
+	// maxFailures defines max number of body failures in failure message
+	maxFailures = 20
+
+	// maxBodyLength defines max length of body in failure message
+	maxBodyLength = 4096
+)
+
 // ResponseHandler defines handler for response
 // It implements gomegatypes.GomegaMatcher
 type ResponseHandler interface {
@@ -31,12 +41,41 @@ type ResponseHandler interface {
 type ResponseMatcher struct {
 	bodyMatcher gomegatypes.GomegaMatcher
 
+	// expected is expected body which is used to show diff
+	expected interface{}
+
 	// emptyBody used to validate that body is empty
 	emptyBody bool
 
-	code int
+	// code defines matcher of status code
+	code gomegatypes.GomegaMatcher
+
+	// expectedCode defines expression of expected status code
+	expectedCode string
+
+	headers *headerMatcher
+
+	cookies *cookieMatcher
+
+	tls *tlsMatcher
+
+	// redirects defines matcher of redirect chain
+	redirects gomegatypes.GomegaMatcher
+
+	// binary defines checks of binary body
+	binary *runtime.Binary
+
+	// saveTo defines variable name of path which body is saved to
+	saveTo string
+
+	// encoding defines expected content encoding
+	encoding string
+
+	// proto defines expected protocol of response
+	proto string
 
-	headers map[string]string
+	// expectTimeout defines that request is expected to time out
+	expectTimeout bool
 
 	defs []runtime.Definition
 
@@ -49,6 +88,19 @@ type ResponseMatcher struct {
 	lastFailures []error
 }
 
+// parseMatcher parses matcher of response other than body
+// Values captured by such matchers can't be exported, so $capture is rejected
+func parseMatcher(expected []byte) (gomegatypes.GomegaMatcher, error) {
+	m, err := matcher.Parse(expected)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := m.(matcher.Capturer); ok {
+		return nil, fmt.Errorf("$capture is only supported in body")
+	}
+	return m, nil
+}
+
 // MatchResponse returns a response matcher
 func MatchResponse(rt *runtime.RoundTrip) (ResponseHandler, error) {
 	if rt == nil {
@@ -56,9 +108,49 @@ func MatchResponse(rt *runtime.RoundTrip) (ResponseHandler, error) {
 	}
 	resp := rt.Response
 	rm := &ResponseMatcher{
-		code:    resp.StatusCode,
-		headers: resp.Headers,
-		defs:    rt.Definitions,
+		defs:     rt.Definitions,
+		binary:   resp.Binary,
+		saveTo:   resp.SaveTo,
+		encoding: resp.Encoding,
+		proto:    resp.Proto,
+
+		expectTimeout: resp.ExpectTimeout,
+	}
+	if len(resp.StatusCode) != 0 {
+		code, err := newStatusMatcher(resp.StatusCode)
+		if err != nil {
+			return nil, err
+		}
+		rm.code = code
+		rm.expectedCode = string(resp.StatusCode)
+	}
+	if len(resp.Headers) != 0 {
+		hm, err := newHeaderMatcher(resp.Headers)
+		if err != nil {
+			return nil, err
+		}
+		rm.headers = hm
+	}
+	if len(resp.TLS) != 0 {
+		tm, err := newTLSMatcher(resp.TLS)
+		if err != nil {
+			return nil, err
+		}
+		rm.tls = tm
+	}
+	if len(resp.Redirects) != 0 {
+		rdm, err := parseMatcher(resp.Redirects)
+		if err != nil {
+			return nil, fmt.Errorf("parse redirects matcher error: %v", err)
+		}
+		rm.redirects = rdm
+	}
+	if len(resp.Cookies) != 0 {
+		cm, err := newCookieMatcher(resp.Cookies)
+		if err != nil {
+			return nil, err
+		}
+		rm.cookies = cm
 	}
 	if resp.Body == nil {
 		return rm, nil
@@ -69,11 +161,17 @@ func MatchResponse(rt *runtime.RoundTrip) (ResponseHandler, error) {
 		return rm, nil
 	}
 
-	m, err := matcher.Parse(resp.Body)
+	m, err := matcher.ParseWithOptions(resp.Body, &matcher.Options{
+		Strict:        resp.Strict,
+		AllowedFields: resp.AllowedFields,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("parse json error: %v", err)
 	}
 	rm.bodyMatcher = m
+	if err := json.Unmarshal(resp.Body, &rm.expected); err != nil {
+		return nil, fmt.Errorf("parse json error: %v", err)
+	}
 	return rm, nil
 }
 
@@ -110,6 +208,9 @@ func assertResponse(obj interface{}) (*http.Response, error) {
 // Match implements gomegatypes.GomegaMatcher
 func (m *ResponseMatcher) Match(actual interface{}) (bool, error) {
 	resp, err := assertResponse(actual)
+	if m.expectTimeout {
+		return m.matchTimeout(resp, err)
+	}
 	if err != nil {
 		return false, err
 	}
@@ -121,42 +222,75 @@ func (m *ResponseMatcher) Match(actual interface{}) (bool, error) {
 		m.fail("can't read body from response")
 		return false, nil
 	}
-	if m.code != 0 && resp.StatusCode != m.code {
-		m.fail("status code is not matched, expected: %v, actual: %v", m.code, resp.StatusCode)
-		m.fail("api status: %v", string(body))
+	if m.code != nil && !m.matchCode(resp.StatusCode) {
+		m.fail("status code is not matched, expected: %v, actual: %v", m.expectedCode, resp.StatusCode)
+		m.fail("api status: %v", truncate(string(body)))
+	}
+
+	if m.headers != nil {
+		for _, f := range m.headers.match(resp.Header) {
+			m.fail("response header is not matched: %v", f)
+		}
+	}
+
+	if m.cookies != nil {
+		for _, f := range m.cookies.match(resp) {
+			m.fail("response cookie is not matched: %v", f)
+		}
+	}
+
+	if m.tls != nil {
+		for _, f := range m.tls.match(resp.TLS) {
+			m.fail("tls is not matched: %v", f)
+		}
 	}
 
-	for k, v := range m.headers {
-		hv := resp.Header.Get(k)
-		if hv != v {
-			m.fail("response header %v is not matched, expected: %v, actual: %v", k, v, hv)
+	if m.redirects != nil {
+		for _, f := range matchObject(m.redirects, redirectChain(resp)) {
+			m.fail("redirects are not matched: %v", f)
 		}
 	}
 
+	if m.encoding != "" && contentEncoding(resp.Header) != m.encoding {
+		m.fail("content encoding is not matched, expected: %v, actual: %v", m.encoding, contentEncoding(resp.Header))
+	}
+	if m.proto != "" && resp.Proto != m.proto {
+		m.fail("protocol is not matched, expected: %v, actual: %v", m.proto, resp.Proto)
+	}
+
 	if m.emptyBody && len(body) != 0 {
-		m.fail("body should be empty, actual: %v", string(body))
+		m.fail("body should be empty, actual: %v", truncate(string(body)))
 	}
 
-	if m.bodyMatcher != nil {
-		var b interface{}
-		if err := json.Unmarshal(body, &b); err != nil {
-			m.fail("can't unmarshal body(%v) to json, NOW only json Content-Type is supported", string(body))
-			return false, nil
+	if m.binary != nil {
+		for _, f := range matchBinary(m.binary, body) {
+			m.fail("binary body is not matched: %v", f)
+		}
+	}
 
+	// data is body in json which is used by definitions
+	data := body
+	if m.bodyMatcher != nil || m.hasBodyDefinition() {
+		contentType := resp.Header.Get("Content-Type")
+		decoder := codec.ForContentType(contentType)
+		b, err := decoder.Decode(body)
+		if err != nil {
+			m.fail("can't decode body(%v) with Content-Type %q: %v", truncate(string(body)), contentType, err)
+			return false, nil
+		}
+		if decoder != codec.JSON {
+			if data, err = json.Marshal(b); err != nil {
+				m.fail("can't convert body(%v) to json: %v", truncate(string(body)), err)
+				return false, nil
+			}
 		}
-		if err := func() error {
+		if m.bodyMatcher != nil {
 			matched, err := m.bodyMatcher.Match(b)
 			if err != nil {
-				return err
+				m.fail("can't match response body: \n%v", indent.Indent(err.Error(), "\t"))
 			} else if !matched {
-				return errors.New(
-					indent.Indent(m.bodyMatcher.FailureMessage(b), "\t"),
-				)
+				m.fail("can't match response body: \n%v", indent.Indent(m.bodyFailure(b), "\t"))
 			}
-			return nil
-		}(); err != nil {
-			// TODO(zjj2wry): print got data when 'want' not match 'got'
-			m.fail("can't match response body: \n%v", err)
 		}
 	}
 	if len(m.lastFailures) > 0 {
@@ -165,31 +299,130 @@ func (m *ResponseMatcher) Match(actual interface{}) (bool, error) {
 
 	m.vars = map[string]jsonutil.Variable{}
 	isErr := false
-	for _, def := range m.defs {
-		switch def.Type {
-		case runtime.BodyType:
-			v, err := jsonutil.GetVariable(body, def.Name, def.Selector...)
+	for i := range m.defs {
+		def := &m.defs[i]
+		v, err := define(def, resp, body, data)
+		if err != nil {
+			m.fail("can't get variable %s: %v", def.Name, err)
+			isErr = true
+			continue
+		}
+		m.vars[def.Name] = v
+	}
+	if c, ok := m.bodyMatcher.(matcher.Capturer); ok {
+		for name, value := range c.Captures() {
+			if _, ok := m.vars[name]; ok {
+				m.fail("captured variable %s conflicts with definition", name)
+				isErr = true
+				continue
+			}
+			raw, err := json.Marshal(value)
 			if err != nil {
-				m.fail("can't get variable %s: %v", def.Name, err)
+				m.fail("can't marshal captured variable %s: %v", name, err)
 				isErr = true
+				continue
 			}
-			m.vars[def.Name] = v
-		case runtime.StatusType:
-			m.vars[def.Name] = jsonutil.NewIntVariable(def.Name, int64(resp.StatusCode))
-		case runtime.HeaderType:
-			if len(def.Selector) != 1 {
-				m.fail("header definition expected selector with len 1, actual is %v", def.Selector)
+			v, err := jsonutil.GetVariable(raw, name)
+			if err != nil {
+				m.fail("can't get captured variable %s: %v", name, err)
+				isErr = true
+				continue
 			}
-			m.vars[def.Name] = jsonutil.NewStringVariable(def.Name, resp.Header.Get(def.Selector[0]))
+			m.vars[name] = v
 		}
 	}
 	if isErr {
 		return false, nil
 	}
+	if m.saveTo != "" {
+		if _, ok := m.vars[m.saveTo]; ok {
+			m.fail("variable %s of saved body conflicts with definition", m.saveTo)
+			return false, nil
+		}
+		path, err := saveBody(body)
+		if err != nil {
+			m.fail("can't save body: %v", err)
+			return false, nil
+		}
+		m.vars[m.saveTo] = jsonutil.NewStringVariable(m.saveTo, path)
+	}
+	m.parsed = true
+	return true, nil
+}
+
+// matchTimeout succeeds if request or reading body is timed out
+func (m *ResponseMatcher) matchTimeout(resp *http.Response, err error) (bool, error) {
+	m.lastFailures = nil
+	if err == nil {
+		defer close.Close(resp.Body)
+		_, err = ioutil.ReadAll(resp.Body)
+		if err == nil {
+			m.fail("request is expected to time out, but response is received with status code %v", resp.StatusCode)
+			return false, nil
+		}
+	}
+	if !IsTimeout(err) {
+		return false, err
+	}
+	m.vars = map[string]jsonutil.Variable{}
 	m.parsed = true
 	return true, nil
 }
 
+// hasBodyDefinition returns true if variables are defined from body
+func (m *ResponseMatcher) hasBodyDefinition() bool {
+	for _, def := range m.defs {
+		// regexp without selector is applied to raw body
+		if def.Type == runtime.BodyType && (def.Regexp == nil || len(def.Selector) != 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCode returns true if status code is matched
+func (m *ResponseMatcher) matchCode(code int) bool {
+	matched, err := m.code.Match(float64(code))
+	return err == nil && matched
+}
+
+// bodyFailure returns failures of body with full paths and
+// diff between expected and actual body
+func (m *ResponseMatcher) bodyFailure(actual interface{}) string {
+	failures := matcher.Flatten(m.bodyMatcher, actual)
+	lines := []string{}
+	for i, f := range failures {
+		if i == maxFailures {
+			lines = append(lines, fmt.Sprintf("... and %v more failures", len(failures)-maxFailures))
+			break
+		}
+		lines = append(lines, f.String())
+	}
+
+	expected, err := json.MarshalIndent(matcher.ExpectedView(m.expected, actual, failures), "", "  ")
+	if err != nil {
+		return strings.Join(lines, "\n")
+	}
+	got, err := json.MarshalIndent(actual, "", "  ")
+	if err != nil {
+		return strings.Join(lines, "\n")
+	}
+	d, err := diff.Unified(string(expected), string(got), !config.DefaultReporterConfig.NoColor)
+	if err != nil || d == "" {
+		return strings.Join(lines, "\n")
+	}
+	lines = append(lines, "diff (-expected +actual):", d)
+	return strings.Join(lines, "\n")
+}
+
+// truncate truncates huge body in failure message
+func truncate(body string) string {
+	if len(body) <= maxBodyLength {
+		return body
+	}
+	return fmt.Sprintf("%v...(%v bytes truncated)", body[:maxBodyLength], len(body)-maxBodyLength)
+}
+
 func (m *ResponseMatcher) fail(templ string, args ...interface{}) {
 	err := fmt.Errorf(templ, args...)
 	m.lastFailures = append(m.lastFailures, err)
@@ -214,3 +447,28 @@ func (m *ResponseMatcher) FailureMessage(actual interface{}) (message string) {
 func (m *ResponseMatcher) NegatedFailureMessage(actual interface{}) (message string) {
 	return format.Message(reflect.TypeOf(actual).Name(), "not to match response")
 }
+
+// matchObject matches object by a json matcher and returns failures
+func matchObject(m gomegatypes.GomegaMatcher, obj interface{}) []string {
+	// convert to json types so that numbers can be matched
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var actual interface{}
+	if err := json.Unmarshal(body, &actual); err != nil {
+		return []string{err.Error()}
+	}
+	matched, err := m.Match(actual)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if matched {
+		return nil
+	}
+	failures := []string{}
+	for _, f := range matcher.Flatten(m, actual) {
+		failures = append(failures, f.String())
+	}
+	return failures
+}
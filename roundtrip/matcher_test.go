@@ -0,0 +1,136 @@
+package roundtrip
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/caicloud/aloe/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newResponse(code int, contentType string, body string) *http.Response {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return &http.Response{
+		StatusCode: code,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestMatchResponseWithCodec(t *testing.T) {
+	cases := []struct {
+		desc        string
+		contentType string
+		body        string
+		expected    string
+		selector    []string
+		value       string
+	}{
+		{
+			"json",
+			"application/json",
+			`{"product": {"id": "1", "tags": ["a", "b"]}}`,
+			`{"product": {"id": {"$regexp": "^[0-9]+$"}}}`,
+			[]string{"product", "id"},
+			"1",
+		},
+		{
+			"xml",
+			"application/xml",
+			`<product id="1"><tag>a</tag><tag>b</tag></product>`,
+			`{"product": {"@id": "1", "tag": {"$contains": ["b"]}}}`,
+			[]string{"product", "tag", "[1]"},
+			"b",
+		},
+		{
+			"yaml",
+			"application/yaml",
+			"product:\n  id: 1\n",
+			`{"product": {"id": 1}}`,
+			[]string{"$.product.id"},
+			"1",
+		},
+		{
+			"form",
+			"application/x-www-form-urlencoded",
+			`id=1&tag=a&tag=b`,
+			`{"id": "1", "tag": ["a", "b"]}`,
+			[]string{"tag"},
+			`["a","b"]`,
+		},
+		{
+			"text",
+			"text/plain",
+			`pong`,
+			`{"$regexp": "^po"}`,
+			[]string{},
+			"pong",
+		},
+		{
+			"json served as text",
+			"text/plain; charset=utf-8",
+			`{"a": 1}`,
+			`{"a": 1}`,
+			[]string{"a"},
+			"1",
+		},
+		{
+			"number served as text",
+			"text/plain; charset=utf-8",
+			`42`,
+			`"42"`,
+			[]string{},
+			"42",
+		},
+	}
+	for _, c := range cases {
+		rt := &runtime.RoundTrip{
+			Response: runtime.Response{
+				StatusCode: []byte(`200`),
+				Body:       []byte(c.expected),
+			},
+			Definitions: []runtime.Definition{
+				{
+					Var: runtime.Var{
+						Name:     "v",
+						Selector: c.selector,
+					},
+					Type: runtime.BodyType,
+				},
+			},
+		}
+		m, err := MatchResponse(rt)
+		require.NoError(t, err, c.desc)
+		resp := newResponse(200, c.contentType, c.body)
+		matched, err := m.Match(resp)
+		require.NoError(t, err, c.desc)
+		require.True(t, matched, "%v: %v", c.desc, m.FailureMessage(resp))
+		v, ok := m.Variables()["v"]
+		require.True(t, ok, c.desc)
+		assert.Equal(t, c.value, v.String(), c.desc)
+	}
+}
+
+func TestMatchResponseCaptureOutsideBody(t *testing.T) {
+	cases := []struct {
+		desc string
+		resp runtime.Response
+	}{
+		{"status", runtime.Response{StatusCode: []byte(`{"$capture": "code"}`)}},
+		{"header", runtime.Response{Headers: map[string]string{"X-Id": `{"$capture": "id"}`}}},
+		{"cookie", runtime.Response{Cookies: map[string]string{"session": `{"value": {"$capture": "session"}}`}}},
+		{"redirects", runtime.Response{Redirects: []byte(`{"$capture": "hops"}`)}},
+	}
+	for _, c := range cases {
+		_, err := MatchResponse(&runtime.RoundTrip{
+			RoundTripTemplate: runtime.RoundTripTemplate{Response: c.resp},
+		})
+		assert.Error(t, err, c.desc)
+	}
+}
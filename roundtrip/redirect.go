@@ -0,0 +1,43 @@
+package roundtrip
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// checkRedirect returns redirect policy which follows at most max redirects
+// Redirect response is returned if max is 0
+func checkRedirect(max int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if max == 0 {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > max {
+			return fmt.Errorf("stopped after %d redirects", max)
+		}
+		return nil
+	}
+}
+
+// redirectChain returns redirects followed before response in order
+// Each hop has url, statusCode and location
+func redirectChain(resp *http.Response) []interface{} {
+	hops := []interface{}{}
+	if resp.Request == nil {
+		return hops
+	}
+	for r := resp.Request.Response; r != nil; {
+		hop := map[string]interface{}{
+			"statusCode": r.StatusCode,
+			"location":   r.Header.Get("Location"),
+		}
+		if r.Request == nil {
+			hops = append([]interface{}{hop}, hops...)
+			break
+		}
+		hop["url"] = r.Request.URL.String()
+		hops = append([]interface{}{hop}, hops...)
+		r = r.Request.Response
+	}
+	return hops
+}
@@ -0,0 +1,85 @@
+package roundtrip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/caicloud/aloe/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.Redirect(w, r, "/home", http.StatusFound)
+		case "/home":
+			http.Redirect(w, r, "/dashboard", http.StatusMovedPermanently)
+		}
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	do := func(max *int) (*http.Response, error) {
+		return doRequest(http.DefaultClient, &runtime.Request{
+			Host:         u.Host,
+			Method:       http.MethodGet,
+			Path:         "/login",
+			MaxRedirects: max,
+		})
+	}
+
+	zero, one := 0, 1
+	resp, err := do(&zero)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/home", resp.Header.Get("Location"))
+	assert.Empty(t, redirectChain(resp))
+
+	_, err = do(&one)
+	assert.Error(t, err)
+
+	// body is closed by response matcher
+	resp, err = do(nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	m, err := MatchResponse(&runtime.RoundTrip{
+		RoundTripTemplate: runtime.RoundTripTemplate{
+			Response: runtime.Response{
+				Redirects: []byte(`[
+					{"statusCode": 302, "location": "/home"},
+					{"statusCode": 301, "location": "/dashboard", "url": {"$regexp": "/home$"}}
+				]`),
+			},
+		},
+		Definitions: []runtime.Definition{
+			{
+				Var:  runtime.Var{Name: "next", Selector: []string{"$[0].location"}},
+				Type: runtime.RedirectsType,
+			},
+		},
+	})
+	require.NoError(t, err)
+	matched, err := m.Match(resp)
+	require.NoError(t, err)
+	require.True(t, matched, m.FailureMessage(nil))
+	assert.Equal(t, "/home", m.Variables()["next"].String())
+
+	m, err = MatchResponse(&runtime.RoundTrip{
+		RoundTripTemplate: runtime.RoundTripTemplate{
+			Response: runtime.Response{
+				Redirects: []byte(`{"$len": 1}`),
+			},
+		},
+	})
+	require.NoError(t, err)
+	matched, err = m.Match(newResponse(200, "", ""))
+	require.NoError(t, err)
+	assert.False(t, matched)
+}
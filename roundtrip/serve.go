@@ -0,0 +1,164 @@
+package roundtrip
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+// servedHandler defines a handler served by managed test servers
+// Plain and tls requests are served by different servers
+type servedHandler struct {
+	server    *httptest.Server
+	tlsServer *httptest.Server
+
+	// roots contains the auto-generated CA which signs
+	// certificate of tls server
+	roots *x509.CertPool
+}
+
+// Serve implements Clientset interface
+func (cs *clientset) Serve(host string, h http.Handler) error {
+	if host == "" {
+		return fmt.Errorf("host of handler is not defined")
+	}
+	// handlers are served by hostname regardless of port
+	host = hostname(host)
+	if _, ok := cs.served[host]; ok {
+		return fmt.Errorf("host %v is already served", host)
+	}
+	cert, roots, err := newServingCert(host)
+	if err != nil {
+		return fmt.Errorf("can't generate certificate of %v: %v", host, err)
+	}
+	tlsServer := httptest.NewUnstartedServer(h)
+	tlsServer.TLS = &tls.Config{
+		Certificates: []tls.Certificate{*cert},
+	}
+	tlsServer.StartTLS()
+	cs.served[host] = &servedHandler{
+		server:    httptest.NewServer(h),
+		tlsServer: tlsServer,
+		roots:     roots,
+	}
+	return nil
+}
+
+// Close implements Clientset interface
+func (cs *clientset) Close() {
+	for host, s := range cs.served {
+		s.server.Close()
+		s.tlsServer.Close()
+		delete(cs.served, host)
+	}
+	// clients of dedicated transports may dial closed servers
+	for _, c := range cs.transports {
+		if t, ok := c.Transport.(*http.Transport); ok {
+			t.CloseIdleConnections()
+		}
+	}
+	cs.transports = map[transportKey]*http.Client{}
+}
+
+// serve routes connections to host into test servers of the handler
+// Connections to other hosts, e.g. hosts of redirects, are dialed as usual
+func (s *servedHandler) serve(t *http.Transport, name string) {
+	t.Proxy = nil
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if hostname(addr) == name {
+			addr = s.server.Listener.Addr().String()
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	}
+
+	base := t.TLSClientConfig
+	t.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		config := &tls.Config{}
+		if base != nil {
+			config = base.Clone()
+		}
+		if config.ServerName == "" {
+			config.ServerName = hostname(addr)
+		}
+		if hostname(addr) == name {
+			addr = s.tlsServer.Listener.Addr().String()
+			config.RootCAs = s.roots
+		}
+		d := &tls.Dialer{Config: config}
+		return d.DialContext(ctx, network, addr)
+	}
+}
+
+// hostname returns host without port
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// newServingCert generates a CA and a serving certificate of host signed by it
+func newServingCert(host string) (*tls.Certificate, *x509.CertPool, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "aloe ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	ca, err = x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	leaf := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		leaf.IPAddresses = []net.IP{ip}
+	} else {
+		leaf.DNSNames = []string{host}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, leaf, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	return &tls.Certificate{
+		Certificate: [][]byte{der, caDER},
+		PrivateKey:  key,
+	}, roots, nil
+}
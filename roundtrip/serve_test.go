@@ -0,0 +1,52 @@
+package roundtrip
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/caicloud/aloe/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe(t *testing.T) {
+	cs := NewClientset(nil)
+	defer cs.Close()
+	require.NoError(t, cs.Serve("products", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusFound)
+			return
+		}
+		w.Write([]byte(r.Host + r.URL.Path))
+	})))
+	assert.Error(t, cs.Serve("products", http.NotFoundHandler()))
+	assert.Error(t, cs.Serve("products:8080", http.NotFoundHandler()))
+
+	do := func(scheme, host, path string) string {
+		resp, err := cs.DoRequest(&runtime.RoundTrip{
+			RoundTripTemplate: runtime.RoundTripTemplate{
+				Request: runtime.Request{
+					Scheme: scheme,
+					Host:   host,
+					Method: http.MethodGet,
+					Path:   path,
+				},
+			},
+		})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		if scheme == "https" {
+			require.NotNil(t, resp.TLS)
+			assert.Equal(t, []string{"products"}, resp.TLS.PeerCertificates[0].DNSNames)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+	assert.Equal(t, "products/a", do("", "products", "/a"))
+	assert.Equal(t, "products/new", do("https", "products", "/old"))
+	// hosts are served by hostname regardless of port
+	assert.Equal(t, "products:8080/a", do("", "products:8080", "/a"))
+	assert.Equal(t, "products:8443/new", do("https", "products:8443", "/old"))
+}
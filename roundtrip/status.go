@@ -0,0 +1,113 @@
+package roundtrip
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/caicloud/aloe/matcher"
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	gomegatypes "github.com/onsi/gomega/types"
+)
+
+var (
+	statusClassRegexp = regexp.MustCompile(`^([1-5])xx$`)
+	statusRangeRegexp = regexp.MustCompile(`^([0-9]{3})-([0-9]{3})$`)
+	statusCodeRegexp  = regexp.MustCompile(`^[0-9]{3}$`)
+)
+
+// newStatusMatcher returns matcher of status code from json expression
+// Expression can be a code (200), a list ([200, 204]), a class ("2xx"),
+// a range ("400-499") or a special matcher object ({"$not": 500})
+func newStatusMatcher(raw []byte) (gomegatypes.GomegaMatcher, error) {
+	var expr interface{}
+	if err := json.Unmarshal(raw, &expr); err != nil {
+		return nil, fmt.Errorf("can't parse status code %v: %v", string(raw), err)
+	}
+	if _, ok := expr.(map[string]interface{}); ok {
+		return parseMatcher(raw)
+	}
+	return statusExprMatcher(expr)
+}
+
+func statusExprMatcher(expr interface{}) (gomegatypes.GomegaMatcher, error) {
+	switch e := expr.(type) {
+	case float64:
+		if e != float64(int(e)) {
+			return nil, fmt.Errorf("status code MUST be an integer, actual: %v", e)
+		}
+		return gomega.BeNumerically("==", e), nil
+	case string:
+		if m := statusClassRegexp.FindStringSubmatch(e); m != nil {
+			class, _ := strconv.Atoi(m[1])
+			return matchStatusRange(class*100, class*100+99), nil
+		}
+		if m := statusRangeRegexp.FindStringSubmatch(e); m != nil {
+			lo, _ := strconv.Atoi(m[1])
+			hi, _ := strconv.Atoi(m[2])
+			if lo > hi {
+				return nil, fmt.Errorf("invalid status code range %v", e)
+			}
+			return matchStatusRange(lo, hi), nil
+		}
+		if statusCodeRegexp.MatchString(e) {
+			code, _ := strconv.Atoi(e)
+			return gomega.BeNumerically("==", code), nil
+		}
+		return nil, fmt.Errorf("status code string MUST be a code, a class like 2xx or a range like 400-499, actual: %v", e)
+	case []interface{}:
+		if len(e) == 0 {
+			return nil, fmt.Errorf("list of status code can't be empty")
+		}
+		ms := make([]gomegatypes.GomegaMatcher, 0, len(e))
+		for _, sub := range e {
+			var m gomegatypes.GomegaMatcher
+			var err error
+			if _, ok := sub.(map[string]interface{}); ok {
+				b, _ := json.Marshal(sub)
+				m, err = parseMatcher(b)
+			} else {
+				m, err = statusExprMatcher(sub)
+			}
+			if err != nil {
+				return nil, err
+			}
+			ms = append(ms, m)
+		}
+		return matcher.MatchAnyOf(ms...), nil
+	}
+	return nil, fmt.Errorf("unexpected status code type %T", expr)
+}
+
+func matchStatusRange(lo, hi int) gomegatypes.GomegaMatcher {
+	return &statusRangeMatcher{
+		lo: lo,
+		hi: hi,
+	}
+}
+
+// statusRangeMatcher checks whether status code is in [lo, hi]
+type statusRangeMatcher struct {
+	lo, hi int
+}
+
+// Match implements gomegatypes.GomegaMatcher
+func (m *statusRangeMatcher) Match(actual interface{}) (bool, error) {
+	code, ok := actual.(float64)
+	if !ok {
+		return false, fmt.Errorf("%v is type %T, expected float64", actual, actual)
+	}
+	return code >= float64(m.lo) && code <= float64(m.hi), nil
+}
+
+// FailureMessage implements gomegatypes.GomegaMatcher
+func (m *statusRangeMatcher) FailureMessage(actual interface{}) (message string) {
+	return format.Message(actual, fmt.Sprintf("to be in range %v-%v", m.lo, m.hi))
+}
+
+// NegatedFailureMessage implements gomegatypes.GomegaMatcher
+func (m *statusRangeMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return format.Message(actual, fmt.Sprintf("not to be in range %v-%v", m.lo, m.hi))
+}
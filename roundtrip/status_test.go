@@ -0,0 +1,63 @@
+package roundtrip
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/caicloud/aloe/runtime"
+	"github.com/caicloud/aloe/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusMatcher(t *testing.T) {
+	cases := []struct {
+		expr     string
+		code     int
+		expected bool
+	}{
+		{`200`, 200, true},
+		{`200`, 201, false},
+		{`"204"`, 204, true},
+		{`[200, 204]`, 204, true},
+		{`[200, 204]`, 201, false},
+		{`"2xx"`, 299, true},
+		{`"2xx"`, 300, false},
+		{`"400-499"`, 404, true},
+		{`"400-499"`, 500, false},
+		{`["2xx", 404]`, 404, true},
+		{`{"$not": 500}`, 200, true},
+		{`{"$not": 500}`, 500, false},
+		{`{"$oneOf": [200, 201]}`, 201, true},
+	}
+	for _, c := range cases {
+		m, err := newStatusMatcher([]byte(c.expr))
+		require.NoError(t, err, c.expr)
+		matched, err := m.Match(float64(c.code))
+		require.NoError(t, err, c.expr)
+		assert.Equal(t, c.expected, matched, "%v should match %v: %v", c.expr, c.code, c.expected)
+	}
+
+	for _, expr := range []string{`200.5`, `"6xx"`, `"499-400"`, `"ok"`, `[]`, `true`} {
+		_, err := newStatusMatcher([]byte(expr))
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestZeroStatusCode(t *testing.T) {
+	code := types.StatusCode{}
+	require.NoError(t, json.Unmarshal([]byte(`0`), &code))
+	assert.Empty(t, code.Raw())
+
+	m, err := MatchResponse(&runtime.RoundTrip{
+		RoundTripTemplate: runtime.RoundTripTemplate{
+			Response: runtime.Response{
+				StatusCode: code.Raw(),
+			},
+		},
+	})
+	require.NoError(t, err)
+	matched, err := m.Match(newResponse(500, "", ""))
+	require.NoError(t, err)
+	assert.True(t, matched)
+}
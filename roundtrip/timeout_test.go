@@ -0,0 +1,82 @@
+package roundtrip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/caicloud/aloe/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stream" {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+		}
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	do := func(path string, timeout time.Duration, deadline time.Time) (*http.Response, error) {
+		return doRequest(http.DefaultClient, &runtime.Request{
+			Host:     u.Host,
+			Method:   http.MethodGet,
+			Path:     path,
+			Timeout:  timeout,
+			Deadline: deadline,
+		})
+	}
+
+	_, err = do("/", 50*time.Millisecond, time.Time{})
+	require.Error(t, err)
+	assert.True(t, IsTimeout(err))
+
+	// deadline of case is earlier than timeout of request
+	_, err = do("/", time.Minute, time.Now().Add(50*time.Millisecond))
+	require.Error(t, err)
+	assert.True(t, IsTimeout(err))
+
+	newMatcher := func() ResponseHandler {
+		m, err := MatchResponse(&runtime.RoundTrip{
+			RoundTripTemplate: runtime.RoundTripTemplate{
+				Response: runtime.Response{
+					ExpectTimeout: true,
+				},
+			},
+		})
+		require.NoError(t, err)
+		return m
+	}
+
+	resp, err := do("/", 50*time.Millisecond, time.Time{})
+	m := newMatcher()
+	matched, err := m.Match(&Response{Resp: resp, Err: err})
+	require.NoError(t, err)
+	assert.True(t, matched)
+
+	// header is received but reading body is timed out
+	resp, err = do("/stream", 50*time.Millisecond, time.Time{})
+	require.NoError(t, err)
+	m = newMatcher()
+	matched, err = m.Match(&Response{Resp: resp, Err: err})
+	require.NoError(t, err)
+	assert.True(t, matched)
+
+	m = newMatcher()
+	matched, err = m.Match(newResponse(200, "", ""))
+	require.NoError(t, err)
+	assert.False(t, matched)
+	assert.Contains(t, m.FailureMessage(nil), "expected to time out")
+}
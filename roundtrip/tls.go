@@ -0,0 +1,32 @@
+package roundtrip
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	gomegatypes "github.com/onsi/gomega/types"
+)
+
+// tlsMatcher matches tls connection state of response
+type tlsMatcher struct {
+	matcher gomegatypes.GomegaMatcher
+}
+
+// newTLSMatcher returns a matcher of tls connection state
+func newTLSMatcher(expected []byte) (*tlsMatcher, error) {
+	m, err := parseMatcher(expected)
+	if err != nil {
+		return nil, fmt.Errorf("parse tls matcher error: %v", err)
+	}
+	return &tlsMatcher{
+		matcher: m,
+	}, nil
+}
+
+// match returns failures of tls connection state
+func (tm *tlsMatcher) match(state *tls.ConnectionState) []string {
+	if state == nil {
+		return []string{"connection is not secure"}
+	}
+	return matchObject(tm.matcher, tlsObject(state))
+}
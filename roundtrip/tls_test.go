@@ -0,0 +1,89 @@
+package roundtrip
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caicloud/aloe/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ca := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(ca, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	}), 0666))
+
+	req := &runtime.Request{Scheme: "https", Host: u.Host, Method: http.MethodGet, Path: "/"}
+	cases := []struct {
+		desc     string
+		tls      runtime.TLS
+		hasError bool
+	}{
+		{"system CA", runtime.TLS{}, true},
+		{"custom CA", runtime.TLS{CA: ca, MinVersion: "1.2"}, false},
+		{"insecure", runtime.TLS{InsecureSkipVerify: true}, false},
+		{"wrong server name", runtime.TLS{CA: ca, ServerName: "aloe.test"}, true},
+		{"missing client cert", runtime.TLS{Cert: filepath.Join(dir, "cert.pem"), Key: filepath.Join(dir, "key.pem")}, true},
+	}
+	for _, c := range cases {
+		client, err := NewClient(&runtime.Client{TLS: &c.tls})
+		if err == nil {
+			var resp *http.Response
+			resp, err = doRequest(client, req)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}
+		if c.hasError {
+			assert.Error(t, err, c.desc)
+			continue
+		}
+		assert.NoError(t, err, c.desc)
+	}
+
+	client, err := NewClient(&runtime.Client{TLS: &runtime.TLS{CA: ca}})
+	require.NoError(t, err)
+	resp, err := doRequest(client, req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	tm, err := newTLSMatcher([]byte(`{
+		"version": {"$regexp": "^TLS 1\\.[23]$"},
+		"peerCertificates": {"$any": {
+			"dnsNames": {"$contains": ["example.com"]},
+			"ipAddresses": {"$contains": ["127.0.0.1"]},
+			"notAfter": {"$time": {"after": "now+720h"}}
+		}}
+	}`))
+	require.NoError(t, err)
+	assert.Empty(t, tm.match(resp.TLS))
+	assert.Equal(t, []string{"connection is not secure"}, tm.match(nil))
+
+	tm, err = newTLSMatcher([]byte(`{"serverName": "aloe.test"}`))
+	require.NoError(t, err)
+	assert.Len(t, tm.match(resp.TLS), 1)
+
+	v, err := define(&runtime.Definition{
+		Var:  runtime.Var{Name: "san", Selector: []string{"$.peerCertificates[0].dnsNames[0]"}},
+		Type: runtime.TLSType,
+	}, resp, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", v.String())
+}
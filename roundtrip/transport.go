@@ -0,0 +1,101 @@
+package roundtrip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/caicloud/aloe/runtime"
+)
+
+const (
+	// UnixScheme means request is sent to a unix domain socket
+	// Host of request is path of the socket, e.g. /var/run/app.sock
+	UnixScheme = "unix"
+	// H2CScheme means request is sent in cleartext HTTP/2
+	H2CScheme = "h2c"
+
+	// unixPrefix is prefix of host which is a unix socket url
+	// e.g. unix:///var/run/app.sock
+	unixPrefix = UnixScheme + "://"
+)
+
+// transportKey defines key of clients with dedicated transports
+type transportKey struct {
+	client *http.Client
+	scheme string
+	// target is path of unix socket or served host
+	target string
+}
+
+// dialTarget returns scheme of request and path of unix socket
+// Host with unix prefix means unix scheme no matter what scheme is
+func dialTarget(req *runtime.Request) (string, string) {
+	if strings.HasPrefix(req.Host, unixPrefix) {
+		return UnixScheme, strings.TrimPrefix(req.Host, unixPrefix)
+	}
+	if req.Scheme == UnixScheme {
+		return UnixScheme, req.Host
+	}
+	return req.Scheme, ""
+}
+
+// withTransport returns a copy of client with dedicated transport
+// if scheme of request is unix or h2c or host of request is served in process,
+// otherwise client itself is returned
+// Copies are cached so that connections can be reused
+func (cs *clientset) withTransport(c *http.Client, req *runtime.Request) (*http.Client, error) {
+	scheme, target := dialTarget(req)
+	served, isServed := cs.served[hostname(req.Host)]
+	switch {
+	case scheme == UnixScheme, scheme == H2CScheme:
+	case isServed:
+		// scheme is not a part of key because both plain and tls
+		// connections are routed by the same transport
+		scheme, target = "", hostname(req.Host)
+	default:
+		return c, nil
+	}
+	key := transportKey{
+		client: c,
+		scheme: scheme,
+		target: target,
+	}
+	if tc, ok := cs.transports[key]; ok {
+		return tc, nil
+	}
+
+	base := http.DefaultTransport
+	if c.Transport != nil {
+		base = c.Transport
+	}
+	bt, ok := base.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("can't build dedicated transport of %v from custom transport %T", req.Host, base)
+	}
+	t := bt.Clone()
+	switch scheme {
+	case UnixScheme:
+		if target == "" {
+			return nil, fmt.Errorf("path of unix socket is not defined in host")
+		}
+		t.Proxy = nil
+		t.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", target)
+		}
+	case H2CScheme:
+		// only cleartext HTTP/2 with prior knowledge is allowed
+		t.Protocols = &http.Protocols{}
+		t.Protocols.SetUnencryptedHTTP2(true)
+	default:
+		served.serve(t, target)
+	}
+
+	tc := *c
+	tc.Transport = t
+	cs.transports[key] = &tc
+	return &tc, nil
+}
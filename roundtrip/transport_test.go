@@ -0,0 +1,109 @@
+package roundtrip
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caicloud/aloe/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func protoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	})
+}
+
+func TestUnixTransport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aloe")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "app.sock")
+
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(protoHandler())
+	server.Listener = l
+	server.Start()
+	defer server.Close()
+
+	cs := NewClientset(nil)
+	for _, req := range []runtime.Request{
+		{Scheme: UnixScheme, Host: socket},
+		{Scheme: "http", Host: "unix://" + socket},
+	} {
+		req.Method = http.MethodGet
+		req.Path = "/"
+		resp, err := cs.DoRequest(&runtime.RoundTrip{
+			RoundTripTemplate: runtime.RoundTripTemplate{Request: req},
+		})
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "HTTP/1.1", string(body))
+	}
+
+	_, err = cs.DoRequest(&runtime.RoundTrip{
+		RoundTripTemplate: runtime.RoundTripTemplate{
+			Request: runtime.Request{
+				Scheme: UnixScheme,
+				Host:   filepath.Join(dir, "missing.sock"),
+				Method: http.MethodGet,
+			},
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestH2CTransport(t *testing.T) {
+	server := httptest.NewUnstartedServer(protoHandler())
+	server.Config.Protocols = &http.Protocols{}
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	cs := NewClientset(nil)
+	rt := &runtime.RoundTrip{
+		RoundTripTemplate: runtime.RoundTripTemplate{
+			Request: runtime.Request{
+				Scheme: H2CScheme,
+				Host:   u.Host,
+				Method: http.MethodGet,
+				Path:   "/",
+			},
+			Response: runtime.Response{
+				Proto: "HTTP/2.0",
+				Body:  []byte(`"HTTP/2.0"`),
+			},
+		},
+	}
+	resp, err := cs.DoRequest(rt)
+	require.NoError(t, err)
+	m, err := MatchResponse(rt)
+	require.NoError(t, err)
+	matched, err := m.Match(resp)
+	require.NoError(t, err)
+	assert.True(t, matched, m.FailureMessage(nil))
+
+	// the same server still speaks HTTP/1.1 with http scheme
+	rt.Request.Scheme = "http"
+	resp, err = cs.DoRequest(rt)
+	require.NoError(t, err)
+	m, err = MatchResponse(rt)
+	require.NoError(t, err)
+	matched, err = m.Match(resp)
+	require.NoError(t, err)
+	assert.False(t, matched)
+	assert.Contains(t, m.FailureMessage(nil), "protocol is not matched")
+}
@@ -0,0 +1,57 @@
+package runtime
+
+import (
+	"time"
+)
+
+// Client defines config of a named http client
+type Client struct {
+	// Timeout defines timeout of each request
+	Timeout time.Duration
+
+	// Proxy defines url of proxy
+	Proxy string
+
+	// MaxRedirects defines max number of redirects
+	// nil means default policy of http.Client
+	MaxRedirects *int
+
+	// DisableKeepAlives disables http keep-alives
+	DisableKeepAlives bool
+
+	// MaxIdleConnsPerHost defines max idle connections per host
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost defines max connections per host
+	MaxConnsPerHost int
+
+	// TLS defines tls config
+	TLS *TLS
+
+	// Headers defines default headers of requests
+	Headers map[string]string
+
+	// CookieJar defines scope of cookie jar
+	CookieJar string
+}
+
+// TLS defines tls config of client
+type TLS struct {
+	// CA defines path of CA bundle
+	CA string
+
+	// Cert defines path of client certificate
+	Cert string
+
+	// Key defines path of client key
+	Key string
+
+	// ServerName overrides server name
+	ServerName string
+
+	// MinVersion defines min tls version, e.g. 1.2
+	MinVersion string
+
+	// InsecureSkipVerify defines whether certificate of server is verified
+	InsecureSkipVerify bool
+}
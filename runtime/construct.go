@@ -1,6 +1,11 @@
 package runtime
 
-import "github.com/caicloud/aloe/utils/jsonutil"
+import (
+	"net/url"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/caicloud/aloe/utils/jsonutil"
+)
 
 // CopyContext copy content of context from src context
 func CopyContext(dest, src *Context) {
@@ -29,6 +34,18 @@ func ReconstructContext(ctx *Context) error {
 	return nil
 }
 
+// ApplyStrict sets strict mode of round trip template in context
+func ApplyStrict(ctx *Context, s *types.Strict) {
+	if s == nil {
+		return
+	}
+	if ctx.RoundTripTemplate == nil {
+		ctx.RoundTripTemplate = &RoundTripTemplate{}
+	}
+	ctx.RoundTripTemplate.Response.Strict = s.Enabled
+	ctx.RoundTripTemplate.Response.AllowedFields = s.AllowedFields
+}
+
 // CopyRoundTripTemplate will return a copy of round trip
 func CopyRoundTripTemplate(rt *RoundTripTemplate) *RoundTripTemplate {
 	if rt == nil {
@@ -38,7 +55,14 @@ func CopyRoundTripTemplate(rt *RoundTripTemplate) *RoundTripTemplate {
 	nrt := *rt
 
 	nrt.Request.Headers = copyHeader(rt.Request.Headers)
+	nrt.Request.Query = copyValues(rt.Request.Query)
 	nrt.Response.Headers = copyHeader(rt.Response.Headers)
+	if rt.Clients != nil {
+		nrt.Clients = map[string]*Client{}
+		for name, c := range rt.Clients {
+			nrt.Clients[name] = c
+		}
+	}
 
 	return &nrt
 }
@@ -53,3 +77,14 @@ func copyHeader(header map[string]string) map[string]string {
 	}
 	return nh
 }
+
+func copyValues(vs url.Values) url.Values {
+	if vs == nil {
+		return nil
+	}
+	nvs := url.Values{}
+	for k, v := range vs {
+		nvs[k] = append([]string(nil), v...)
+	}
+	return nvs
+}
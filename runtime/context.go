@@ -1,6 +1,8 @@
 package runtime
 
 import (
+	"time"
+
 	"github.com/caicloud/aloe/utils/jsonutil"
 )
 
@@ -26,6 +28,10 @@ type Context struct {
 
 	// RoundTripTemplate defines template of roundtrip
 	RoundTripTemplate *RoundTripTemplate
+
+	// Deadline defines deadline of round trips in the context
+	// Zero means no deadline
+	Deadline time.Time
 }
 
 // Presetter defines presetter args
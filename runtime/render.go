@@ -2,9 +2,14 @@ package runtime
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/caicloud/aloe/types"
+	"github.com/caicloud/aloe/utils/jsonpath"
 	"github.com/caicloud/aloe/utils/jsonutil"
 )
 
@@ -61,6 +66,57 @@ func RenderCleaners(ctx *Context, ccs []types.CleanerConfig) error {
 	return nil
 }
 
+// RenderClients renders configs of clients and saves them into
+// round trip template of context
+// Clients defined in context override clients with same names in parents
+func RenderClients(ctx *Context, ccs map[string]types.Client) error {
+	if len(ccs) == 0 {
+		return nil
+	}
+	if ctx.RoundTripTemplate == nil {
+		ctx.RoundTripTemplate = &RoundTripTemplate{}
+	}
+	if ctx.RoundTripTemplate.Clients == nil {
+		ctx.RoundTripTemplate.Clients = map[string]*Client{}
+	}
+	for name, cc := range ccs {
+		c := &Client{
+			MaxRedirects:        cc.MaxRedirects,
+			DisableKeepAlives:   cc.DisableKeepAlives,
+			MaxIdleConnsPerHost: cc.MaxIdleConnsPerHost,
+			MaxConnsPerHost:     cc.MaxConnsPerHost,
+			CookieJar:           cc.CookieJar,
+		}
+		if cc.Timeout != nil {
+			c.Timeout = cc.Timeout.Duration
+		}
+		if cc.Proxy != nil {
+			proxy, err := cc.Proxy.Render(ctx.Variables)
+			if err != nil {
+				return fmt.Errorf("can't render proxy of client %v: %v", name, err)
+			}
+			c.Proxy = proxy
+		}
+		if cc.TLS != nil {
+			c.TLS = &TLS{
+				CA:                 cc.TLS.CA,
+				Cert:               cc.TLS.Cert,
+				Key:                cc.TLS.Key,
+				ServerName:         cc.TLS.ServerName,
+				MinVersion:         cc.TLS.MinVersion,
+				InsecureSkipVerify: cc.TLS.InsecureSkipVerify,
+			}
+		}
+		headers, err := renderHeader(ctx, nil, cc.Headers)
+		if err != nil {
+			return fmt.Errorf("can't render headers of client %v: %v", name, err)
+		}
+		c.Headers = headers
+		ctx.RoundTripTemplate.Clients[name] = c
+	}
+	return nil
+}
+
 // RenderRoundTrip render template in round trip config with current context
 func RenderRoundTrip(ctx *Context, rtc *types.RoundTrip) (*RoundTrip, error) {
 	cp := CopyRoundTripTemplate(ctx.RoundTripTemplate)
@@ -76,6 +132,16 @@ func RenderRoundTrip(ctx *Context, rtc *types.RoundTrip) (*RoundTrip, error) {
 	if err := renderRequest(ctx, &rt.Request, &rtc.Request); err != nil {
 		return nil, err
 	}
+	if c, ok := rt.Clients[rt.Client]; ok {
+		if rt.Request.Headers == nil {
+			rt.Request.Headers = map[string]string{}
+		}
+		for k, v := range c.Headers {
+			if _, ok := rt.Request.Headers[k]; !ok {
+				rt.Request.Headers[k] = v
+			}
+		}
+	}
 	if err := renderResponse(ctx, &rt.Response, &rtc.Response); err != nil {
 		return nil, err
 	}
@@ -96,7 +162,13 @@ func RenderExports(ctx *Context, exports []types.Var) error {
 		if err := renderVar(ctx, &exportConf, &export); err != nil {
 			return err
 		}
-		v, err := ctx.Variables.Select(export.Selector...)
+		var v jsonutil.Variable
+		var err error
+		if len(export.Selector) == 1 && jsonpath.IsJSONPath(export.Selector[0]) {
+			v, err = jsonutil.SelectPath(ctx.Variables, export.Name, export.Selector[0])
+		} else {
+			v, err = ctx.Variables.Select(export.Selector...)
+		}
 		if err != nil {
 			return fmt.Errorf("can't export var %v: %v", export.Name, err)
 		}
@@ -125,6 +197,16 @@ func splitMethodAndPath(api string) (string, string) {
 	return strings.TrimSpace(s[0]), strings.TrimSpace(s[1])
 }
 
+// escapeAPI escapes rendered value in api
+// Values in query are escaped as query components, and values in path
+// are escaped as a single path segment, so "/" and "?" are escaped too
+func escapeAPI(prefix, value string) string {
+	if strings.Contains(prefix, "?") {
+		return url.QueryEscape(value)
+	}
+	return url.PathEscape(value)
+}
+
 func isAbs(path string) bool {
 	if len(path) < 2 {
 		return false
@@ -151,7 +233,7 @@ func renderRequest(ctx *Context, runtimereq *Request, req *types.Request) error
 		runtimereq.Scheme = scheme
 	}
 	if req.API != nil {
-		api, err := req.API.Render(ctx.Variables)
+		api, err := req.API.RenderEscaped(ctx.Variables, escapeAPI)
 		if err != nil {
 			return err
 		}
@@ -170,6 +252,24 @@ func renderRequest(ctx *Context, runtimereq *Request, req *types.Request) error
 		return err
 	}
 	runtimereq.Headers = currentHeaders
+	cookies, err := renderMap(ctx, req.Cookies)
+	if err != nil {
+		return err
+	}
+	runtimereq.Cookies = cookies
+	if req.Query != nil {
+		query, err := renderValues(ctx, req.Query)
+		if err != nil {
+			return err
+		}
+		if runtimereq.Query == nil {
+			runtimereq.Query = url.Values{}
+		}
+		// query parameters of request override presetted ones
+		for k, v := range query {
+			runtimereq.Query[k] = v
+		}
+	}
 	if req.Body != nil {
 		body, err := req.Body.Render(ctx.Variables)
 		if err != nil {
@@ -177,19 +277,123 @@ func renderRequest(ctx *Context, runtimereq *Request, req *types.Request) error
 		}
 		runtimereq.Body = []byte(body)
 	}
+	if req.RawBody != nil {
+		runtimereq.Body = req.RawBody
+	}
+	if req.Timeout != nil {
+		runtimereq.Timeout = req.Timeout.Duration
+	}
+	runtimereq.Deadline = ctx.Deadline
+	if req.FollowRedirects != nil {
+		max := int(*req.FollowRedirects)
+		runtimereq.MaxRedirects = &max
+	}
+	if req.Compress != "" {
+		runtimereq.Compress = req.Compress
+	}
+	if req.Form != nil {
+		form, err := renderValues(ctx, req.Form)
+		if err != nil {
+			return err
+		}
+		runtimereq.Form = form
+	}
+	if req.Multipart != nil {
+		mp, err := renderMultipart(ctx, req.Multipart, req.BaseDir)
+		if err != nil {
+			return err
+		}
+		runtimereq.Multipart = mp
+	}
 	return nil
 }
 
+func renderMap(ctx *Context, ts map[string]types.Template) (map[string]string, error) {
+	if len(ts) == 0 {
+		return nil, nil
+	}
+	m := map[string]string{}
+	for k, t := range ts {
+		v, err := t.Render(ctx.Variables)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+func renderValues(ctx *Context, vs types.Values) (url.Values, error) {
+	values := url.Values{}
+	for k, ts := range vs {
+		for _, t := range ts {
+			v, err := t.Render(ctx.Variables)
+			if err != nil {
+				return nil, err
+			}
+			values.Add(k, v)
+		}
+	}
+	return values, nil
+}
+
+func renderMultipart(ctx *Context, mp *types.Multipart, baseDir string) (*Multipart, error) {
+	fields, err := renderValues(ctx, mp.Fields)
+	if err != nil {
+		return nil, err
+	}
+	m := &Multipart{
+		Fields: fields,
+	}
+	for _, f := range mp.Files {
+		if f.Path == nil {
+			return nil, fmt.Errorf("path of multipart file %v is not defined", f.Name)
+		}
+		path, err := f.Path.Render(ctx.Variables)
+		if err != nil {
+			return nil, err
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		file := MultipartFile{
+			Name:        f.Name,
+			Path:        path,
+			Filename:    f.Filename,
+			ContentType: f.ContentType,
+		}
+		if file.Filename == "" {
+			file.Filename = filepath.Base(path)
+		}
+		if file.ContentType == "" {
+			file.ContentType = "application/octet-stream"
+		}
+		m.Files = append(m.Files, file)
+	}
+	return m, nil
+}
+
 func renderResponse(ctx *Context, resp *Response, respConf *types.Response) error {
-	if respConf.StatusCode != 0 {
-		resp.StatusCode = respConf.StatusCode
+	if respConf.StatusCode != nil {
+		resp.StatusCode = respConf.StatusCode.Raw()
+	}
+
+	if resp.Headers == nil {
+		resp.Headers = map[string]string{}
+	}
+	for k, v := range respConf.Headers {
+		value, err := v.Render(ctx.Variables)
+		if err != nil {
+			return err
+		}
+		resp.Headers[http.CanonicalHeaderKey(k)] = value
 	}
 
-	currentHeaders, err := renderHeader(ctx, resp.Headers, respConf.Headers)
+	cookies, err := renderMap(ctx, respConf.Cookies)
 	if err != nil {
 		return err
 	}
-	resp.Headers = currentHeaders
+	resp.Cookies = cookies
 
 	if respConf.Body != nil {
 		body, err := respConf.Body.Render(ctx.Variables)
@@ -199,6 +403,33 @@ func renderResponse(ctx *Context, resp *Response, respConf *types.Response) erro
 		resp.Body = []byte(body)
 	}
 
+	if respConf.Binary != nil {
+		b, err := renderBinary(ctx, respConf.Binary)
+		if err != nil {
+			return err
+		}
+		resp.Binary = b
+	}
+	resp.SaveTo = respConf.SaveTo
+	resp.Encoding = respConf.Encoding
+	resp.Proto = respConf.Proto
+	if respConf.TLS != nil {
+		t, err := respConf.TLS.Render(ctx.Variables)
+		if err != nil {
+			return err
+		}
+		resp.TLS = []byte(t)
+	}
+	if respConf.Redirects != nil {
+		r, err := respConf.Redirects.Render(ctx.Variables)
+		if err != nil {
+			return err
+		}
+		resp.Redirects = []byte(r)
+	}
+
+	resp.ExpectTimeout = respConf.ExpectTimeout
+
 	if respConf.Eventually != nil {
 		resp.Async = true
 		if respConf.Eventually.Interval != nil {
@@ -212,6 +443,28 @@ func renderResponse(ctx *Context, resp *Response, respConf *types.Response) erro
 	return nil
 }
 
+func renderBinary(ctx *Context, bc *types.Binary) (*Binary, error) {
+	b := &Binary{
+		Length:      bc.Length,
+		ContentType: bc.ContentType,
+	}
+	if bc.SHA256 != nil {
+		sum, err := bc.SHA256.Render(ctx.Variables)
+		if err != nil {
+			return nil, err
+		}
+		b.SHA256 = strings.ToLower(sum)
+	}
+	if bc.MD5 != nil {
+		sum, err := bc.MD5.Render(ctx.Variables)
+		if err != nil {
+			return nil, err
+		}
+		b.MD5 = strings.ToLower(sum)
+	}
+	return b, nil
+}
+
 func renderVar(ctx *Context, vc *types.Var, v *Var) error {
 	v.Name = vc.Name
 	for _, st := range vc.Selector {
@@ -234,16 +487,33 @@ func renderDefinition(ctx *Context, dcs []types.Definition) ([]Definition, error
 	for _, dc := range dcs {
 		d := Definition{}
 		switch dc.Type {
-		case "body", "header", "status":
+		case "body", "header", "status", "cookie", "headers", "response", "tls", "redirects":
 			d.Type = DefinitionType(dc.Type)
 		case "":
 			d.Type = BodyType
 		default:
-			return nil, fmt.Errorf("can't understand definition type %v: only [body, header, status] is allowed", dc.Type)
+			return nil, fmt.Errorf("can't understand definition type %v: only [body, header, status, cookie, headers, response, tls, redirects] is allowed", dc.Type)
 		}
 		if err := renderVar(ctx, &dc.Var, &d.Var); err != nil {
 			return nil, fmt.Errorf("can't render var in definition: %v", err)
 		}
+		if dc.Regexp != "" {
+			re, err := regexp.Compile(dc.Regexp)
+			if err != nil {
+				return nil, fmt.Errorf("can't compile regexp of definition %v: %v", dc.Name, err)
+			}
+			d.Regexp = re
+		}
+		if dc.Default != nil {
+			if d.Regexp == nil {
+				return nil, fmt.Errorf("default of definition %v is only allowed with regexp", dc.Name)
+			}
+			def, err := dc.Default.Render(ctx.Variables)
+			if err != nil {
+				return nil, fmt.Errorf("can't render default of definition %v: %v", dc.Name, err)
+			}
+			d.Default = &def
+		}
 
 		ds = append(ds, d)
 	}
@@ -259,7 +529,7 @@ func renderHeader(ctx *Context, current map[string]string, headers map[string]ty
 		if err != nil {
 			return nil, err
 		}
-		current[k] = value
+		current[http.CanonicalHeaderKey(k)] = value
 	}
 	return current, nil
 }
@@ -0,0 +1,41 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/caicloud/aloe/types"
+	"github.com/caicloud/aloe/utils/jsonutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderAPI(t *testing.T) {
+	ctx := &Context{
+		Variables: jsonutil.NewVariableMap("", map[string]jsonutil.Variable{
+			"name":    jsonutil.NewStringVariable("name", "a b/c"),
+			"next":    jsonutil.NewStringVariable("next", "/items?page=2"),
+			"query":   jsonutil.NewStringVariable("query", "a&b=c"),
+			"encoded": jsonutil.NewStringVariable("encoded", "a%20b"),
+		}),
+	}
+	cases := []struct {
+		api  string
+		path string
+	}{
+		{"GET /products/%{name}", "/products/a%20b%2Fc"},
+		{"GET /products/%{query}", "/products/a&b=c"},
+		{"GET /products/%{next}", "/products/%2Fitems%3Fpage=2"},
+		{"GET %{raw(next)}", "/items?page=2"},
+		{"GET /products/%{raw(name)}", "/products/a b/c"},
+		{"GET /products?q=%{query}&name=%{name}", "/products?q=a%26b%3Dc&name=a+b%2Fc"},
+		{"GET /products/%{encoded}", "/products/a%2520b"},
+		{"GET /products/%{raw(encoded)}", "/products/a%20b"},
+	}
+	for _, c := range cases {
+		api, err := types.NewTemplate(c.api)
+		require.NoError(t, err, c.api)
+		req := &Request{}
+		require.NoError(t, renderRequest(ctx, req, &types.Request{API: api}), c.api)
+		assert.Equal(t, c.path, req.Path, c.api)
+	}
+}
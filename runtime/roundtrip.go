@@ -1,6 +1,8 @@
 package runtime
 
 import (
+	"net/url"
+	"regexp"
 	"time"
 )
 
@@ -14,6 +16,18 @@ const (
 	HeaderType DefinitionType = "header"
 	// StatusType means definition from status
 	StatusType DefinitionType = "status"
+	// CookieType means definition from cookie set by response
+	CookieType DefinitionType = "cookie"
+	// HeadersType means definition from header map, values of
+	// each header are in an array
+	HeadersType DefinitionType = "headers"
+	// ResponseType means definition from response info,
+	// e.g. status code, protocol, content length and tls
+	ResponseType DefinitionType = "response"
+	// TLSType means definition from tls connection state
+	TLSType DefinitionType = "tls"
+	// RedirectsType means definition from followed redirects
+	RedirectsType DefinitionType = "redirects"
 )
 
 // RoundTripTemplate defines template of round trip
@@ -21,6 +35,9 @@ type RoundTripTemplate struct {
 	// Client defines http client used by this roundtrip
 	Client string
 
+	// Clients defines named clients defined in contexts
+	Clients map[string]*Client
+
 	// Request defines http request
 	Request Request
 
@@ -62,21 +79,106 @@ type Request struct {
 	// Headers defines http request header
 	Headers map[string]string
 
+	// Query defines query parameters of http request
+	Query url.Values
+
+	// Cookies defines cookies of http request
+	Cookies map[string]string
+
 	// Body defines http request body
 	Body []byte
+
+	// Timeout defines timeout of request
+	// Zero means no timeout
+	Timeout time.Duration
+
+	// Deadline defines deadline of request, e.g. deadline of case
+	// Zero means no deadline
+	Deadline time.Time
+
+	// MaxRedirects defines max number of redirects which are followed
+	// Policy of client is used if it is nil
+	MaxRedirects *int
+
+	// Compress defines content encoding of body
+	Compress string
+
+	// Form defines fields of application/x-www-form-urlencoded body
+	Form url.Values
+
+	// Multipart defines multipart/form-data body
+	Multipart *Multipart
+}
+
+// Multipart defines multipart/form-data body
+type Multipart struct {
+	// Fields defines form fields
+	Fields url.Values
+
+	// Files defines files to upload
+	Files []MultipartFile
+}
+
+// MultipartFile defines a file part of multipart body
+type MultipartFile struct {
+	// Name defines form field name
+	Name string
+
+	// Path defines path of the file
+	Path string
+
+	// Filename defines filename in Content-Disposition
+	Filename string
+
+	// ContentType defines Content-Type of the part
+	ContentType string
 }
 
 // Response defines http response
 type Response struct {
-	// StatusCode checks response code
-	StatusCode int
+	// StatusCode defines json expression of expected status code
+	// e.g. 200, [200, 204], "2xx", "400-499" or {"$not": 500}
+	// Status code will not be checked if it is empty
+	StatusCode []byte
 
-	// Headers defines http request header
+	// Headers defines matchers of http response header
+	// Value is a string or a json matcher
 	Headers map[string]string
 
+	// Cookies defines matchers of cookies set by response
+	// Value is a string or a json matcher
+	Cookies map[string]string
+
 	// Body defines http request body
 	Body []byte
 
+	// Strict defines whether fields of object in body
+	// which are not expected will be rejected
+	Strict bool
+
+	// AllowedFields defines paths of fields which are
+	// allowed in strict mode
+	AllowedFields []string
+
+	// Encoding defines expected content encoding of body
+	Encoding string
+
+	// Proto defines expected protocol of response
+	Proto string
+
+	// TLS defines json matcher of tls connection state
+	TLS []byte
+
+	// Redirects defines json matcher of followed redirects
+	Redirects []byte
+
+	// Binary defines checks of binary body
+	Binary *Binary
+
+	// SaveTo defines name of variable which holds path
+	// of the temp file body is saved to
+	SaveTo string
+
 	// Async defines whether the task is a async task
 	Async bool
 
@@ -85,6 +187,9 @@ type Response struct {
 
 	// Interval defines interval of polling and checking
 	Interval time.Duration
+
+	// ExpectTimeout defines that request is expected to time out
+	ExpectTimeout bool
 }
 
 // Var defines variable
@@ -101,9 +206,15 @@ type Definition struct {
 	// Var defines Definition variable
 	Var
 	// Type defines variable from
-	// enum ["body", "status", "header"]
+	// enum ["body", "status", "header", "cookie", "headers", "response", "tls", "redirects"]
 	// default is body
 	Type DefinitionType
+
+	// Regexp defines pattern applied to the raw value
+	Regexp *regexp.Regexp
+
+	// Default defines value if regexp captures nothing
+	Default *string
 }
 
 // When defines roundtrip condition
@@ -114,3 +225,19 @@ type When struct {
 	// Args defines additional args
 	Args map[string]string
 }
+
+// Binary defines checks of binary body
+type Binary struct {
+	// Length defines expected length of body
+	// It will not be checked if it is nil
+	Length *int64
+
+	// SHA256 defines expected sha256 checksum in hex
+	SHA256 string
+
+	// MD5 defines expected md5 checksum in hex
+	MD5 string
+
+	// ContentType defines expected content type detected from body
+	ContentType string
+}
@@ -3,6 +3,7 @@ package template
 import (
 	"strings"
 
+	"github.com/caicloud/aloe/utils/jsonpath"
 	"github.com/caicloud/aloe/utils/jsonutil"
 )
 
@@ -30,8 +31,12 @@ func isExistWithSelector(arg jsonutil.Variable, selector string) (string, error)
 	if arg == nil {
 		return False, nil
 	}
-	selectors := strings.Split(selector, ",")
-	_, err := arg.Select(selectors...)
+	var err error
+	if jsonpath.IsJSONPath(selector) {
+		_, err = jsonutil.SelectPath(arg, arg.Name(), selector)
+	} else {
+		_, err = arg.Select(strings.Split(selector, ",")...)
+	}
 	if err != nil {
 		return False, nil
 	}
@@ -71,6 +71,11 @@ func Call(name string, args ...jsonutil.Variable) (string, error) {
 			return length(args[0])
 		}
 		return "", fmt.Errorf("func len expected 1 arg, but received: %v", len(args))
+	case Raw:
+		if len(args) == 1 {
+			return raw(args[0])
+		}
+		return "", fmt.Errorf("func raw expected 1 arg, but received: %v", len(args))
 	default:
 		return "", fmt.Errorf("unknown function named %v", name)
 	}
@@ -0,0 +1,20 @@
+package template
+
+import (
+	"fmt"
+
+	"github.com/caicloud/aloe/utils/jsonutil"
+)
+
+const (
+	// Raw defines raw function which returns value as it is
+	// Value of raw function is never escaped, e.g. a pre-encoded value in api
+	Raw = "raw"
+)
+
+func raw(v jsonutil.Variable) (string, error) {
+	if v == nil {
+		return "", fmt.Errorf("argument of raw is nil")
+	}
+	return v.String(), nil
+}
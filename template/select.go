@@ -3,6 +3,7 @@ package template
 import (
 	"strings"
 
+	"github.com/caicloud/aloe/utils/jsonpath"
 	"github.com/caicloud/aloe/utils/jsonutil"
 )
 
@@ -17,8 +18,13 @@ func selectVar(v jsonutil.Variable, selector string, ignore bool) (string, error
 	if v == nil {
 		return "", nil
 	}
-	selectors := strings.Split(selector, ",")
-	res, err := v.Select(selectors...)
+	var res jsonutil.Variable
+	var err error
+	if jsonpath.IsJSONPath(selector) {
+		res, err = jsonutil.SelectPath(v, v.Name(), selector)
+	} else {
+		res, err = v.Select(strings.Split(selector, ",")...)
+	}
 	if err != nil {
 		if ignore {
 			return "", nil
@@ -11,8 +11,17 @@ import (
 // Golang template is too complex to use in this case
 type Template interface {
 	Render(vs jsonutil.VariableMap) (string, error)
+
+	// RenderEscaped renders template like Render but values of
+	// variables and functions are escaped by escape func,
+	// except values of raw function
+	// Prefix is the output rendered before the value
+	RenderEscaped(vs jsonutil.VariableMap, escape Escaper) (string, error)
 }
 
+// Escaper escapes value rendered after prefix
+type Escaper func(prefix, value string) string
+
 // Template defines template of request
 type template struct {
 	identitors map[int]identitor
@@ -25,6 +34,11 @@ type identitor struct {
 	isVar bool
 }
 
+// isRaw returns true if identitor is raw function
+func (i *identitor) isRaw() bool {
+	return !i.isVar && i.name == Raw
+}
+
 func (t *template) fromRaw(raw string) error {
 	lexer := NewLexer([]rune(raw))
 
@@ -108,6 +122,15 @@ func New(raw string) (Template, error) {
 // %% => %
 // %%{string} => %{string}
 func (t *template) Render(vs jsonutil.VariableMap) (string, error) {
+	return t.render(vs, nil)
+}
+
+// RenderEscaped implements Template
+func (t *template) RenderEscaped(vs jsonutil.VariableMap, escape Escaper) (string, error) {
+	return t.render(vs, escape)
+}
+
+func (t *template) render(vs jsonutil.VariableMap, escape Escaper) (string, error) {
 	out := ""
 	for i, snippet := range t.snippets {
 		identitor, ok := t.identitors[i]
@@ -119,6 +142,9 @@ func (t *template) Render(vs jsonutil.VariableMap) (string, error) {
 		if err != nil {
 			return "", err
 		}
+		if escape != nil && !identitor.isRaw() {
+			str = escape(out, str)
+		}
 		out += str
 		out += snippet
 	}
@@ -131,6 +157,9 @@ func (t *template) Render(vs jsonutil.VariableMap) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if escape != nil && !identitor.isRaw() {
+		str = escape(out, str)
+	}
 	out += str
 
 	return out, nil
@@ -1,6 +1,8 @@
 package template
 
 import (
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/caicloud/aloe/utils/jsonutil"
@@ -342,3 +344,52 @@ func TestRender(t *testing.T) {
 		assert.Equal(t, c.out, out, "render result should be same")
 	}
 }
+
+func TestRenderSelect(t *testing.T) {
+	list, err := jsonutil.GetVariable([]byte(`{"items": [{"id": 1, "name": "a"}, {"id": 2, "name": "b"}]}`), "list")
+	require.NoError(t, err)
+	vs := jsonutil.NewVariableMap("", map[string]jsonutil.Variable{
+		"list": list,
+	})
+	cases := []struct {
+		raw      string
+		out      string
+		hasError bool
+	}{
+		{"%{select(list, `items,[1],name`)}", "b", false},
+		{"%{select(list, `$.items[1].name`)}", "b", false},
+		{"%{select(list, `$.items[?(@.name == 'b')].id`)}", "[2]", false},
+		{"%{select(list, `$.items[*].id`)}", "[1,2]", false},
+		{"%{select(list, `$.items[5]`)}", "", true},
+		{"%{select(list, `$.items[5]`, `true`)}", "", false},
+		{"%{exist(list, `$.items[0].id`)}", "true", false},
+		{"%{exist(list, `$.items[0].age`)}", "false", false},
+	}
+	for _, c := range cases {
+		templ, err := New(c.raw)
+		require.NoError(t, err, c.raw)
+		out, err := templ.Render(vs)
+		if c.hasError {
+			assert.Error(t, err, c.raw)
+			continue
+		}
+		require.NoError(t, err, c.raw)
+		assert.Equal(t, c.out, out, c.raw)
+	}
+}
+
+func TestRenderEscaped(t *testing.T) {
+	vs := jsonutil.NewVariableMap("", map[string]jsonutil.Variable{
+		"name": jsonutil.NewStringVariable("name", "a b/c?"),
+	})
+	templ, err := New("GET /users/%{name}/%{raw(name)}?x=%{name}")
+	require.NoError(t, err)
+	out, err := templ.RenderEscaped(vs, func(prefix, value string) string {
+		if strings.Contains(prefix, "?") {
+			return url.QueryEscape(value)
+		}
+		return url.PathEscape(value)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "GET /users/a%20b%2Fc%3F/a b/c??x=a+b%2Fc%3F", out)
+}
@@ -10,4 +10,11 @@ type Case struct {
 
 	// Flow defines test flow of a test case
 	Flow []RoundTrip `json:"flow,omitempty"`
+
+	// Timeout defines deadline of all round trips in the case
+	Timeout *Duration `json:"timeout,omitempty"`
+
+	// Strict defines default strict mode of response body
+	// in this case, it overrides strict mode of context
+	Strict *Strict `json:"strict,omitempty"`
 }
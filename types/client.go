@@ -0,0 +1,58 @@
+package types
+
+// Client defines config of a named http client
+type Client struct {
+	// Timeout defines timeout of each request sent by the client
+	Timeout *Duration `json:"timeout,omitempty"`
+
+	// Proxy defines url of proxy, e.g. http://127.0.0.1:3128
+	Proxy *Template `json:"proxy,omitempty"`
+
+	// MaxRedirects defines max number of redirects the client follows
+	// 0 means redirects will not be followed, default is 10
+	MaxRedirects *int `json:"maxRedirects,omitempty"`
+
+	// DisableKeepAlives disables http keep-alives
+	DisableKeepAlives bool `json:"disableKeepAlives,omitempty"`
+
+	// MaxIdleConnsPerHost defines max idle connections to keep per host
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost,omitempty"`
+
+	// MaxConnsPerHost defines max connections per host
+	// 0 means no limit
+	MaxConnsPerHost int `json:"maxConnsPerHost,omitempty"`
+
+	// TLS defines tls config of the client
+	TLS *TLS `json:"tls,omitempty"`
+
+	// Headers defines default headers of requests sent by the client
+	// Headers defined in request override them
+	Headers map[string]Template `json:"headers,omitempty"`
+
+	// CookieJar defines scope of cookie jar, context or case
+	// Cookie jar is disabled if it is empty
+	CookieJar string `json:"cookieJar,omitempty"`
+}
+
+// TLS defines tls config of client
+// Relative paths of files are relative to the context file
+type TLS struct {
+	// CA defines path of CA bundle in PEM which verifies server certificates
+	// System CAs are used if it is empty
+	CA string `json:"ca,omitempty"`
+
+	// Cert defines path of client certificate in PEM
+	Cert string `json:"cert,omitempty"`
+
+	// Key defines path of client key in PEM
+	Key string `json:"key,omitempty"`
+
+	// ServerName overrides server name used to verify certificate and for SNI
+	ServerName string `json:"serverName,omitempty"`
+
+	// MinVersion defines min tls version, one of 1.0, 1.1, 1.2 and 1.3
+	MinVersion string `json:"minVersion,omitempty"`
+
+	// InsecureSkipVerify defines whether certificate of server is verified
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
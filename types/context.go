@@ -3,6 +3,10 @@ package types
 const (
 	// ContextFile defines default filename of spec
 	ContextFile = "context.yaml"
+
+	// FixturesDir defines name of directory which stores shared fixtures
+	// It will not be treated as a context unless it has a context file
+	FixturesDir = "fixtures"
 )
 
 // Context defines some configs for ginkgo.Describe
@@ -18,6 +22,10 @@ type Context struct {
 	// Presetters preset some common fields of round-trip in context
 	Presetters []PresetConfig `json:"presetters,omitempty"`
 
+	// Clients defines named http clients which can be used
+	// by round trips in this context and its children
+	Clients map[string]Client `json:"clients,omitempty"`
+
 	// Flow will be called to construct context
 	Flow []RoundTrip `json:"flow,omitempty"`
 
@@ -26,4 +34,8 @@ type Context struct {
 
 	// Cleaners defines cleaner of the context
 	Cleaners []CleanerConfig `json:"cleaners,omitempty"`
+
+	// Strict defines default strict mode of response body
+	// in this context and its children
+	Strict *Strict `json:"strict,omitempty"`
 }
@@ -1,7 +1,10 @@
 package types
 
 import (
+	"encoding/json"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/caicloud/aloe/template"
@@ -52,27 +55,260 @@ type Request struct {
 	// NOTE(liubog2008): whether to use map[string][]string
 	Headers map[string]Template `json:"headers,omitempty"`
 
+	// Query defines query parameters of request
+	// Value can be a template or a list of templates
+	Query Values `json:"query,omitempty"`
+
+	// Cookies defines cookies of request
+	Cookies map[string]Template `json:"cookies,omitempty"`
+
 	// Body defines a template with variable
 	Body *Template `json:"body,omitempty"`
+
+	// BodyFile defines a file which contains template of body
+	// Relative path is relative to the case file
+	BodyFile string `json:"bodyFile,omitempty"`
+
+	// BinaryBodyFile defines a file which will be sent as body verbatim
+	// Relative path is relative to the case file
+	BinaryBodyFile string `json:"binaryBodyFile,omitempty"`
+
+	// RawBody defines body read from binary body file
+	RawBody []byte `json:"-"`
+
+	// Timeout defines timeout of the request, including reading body
+	// Default timeout of suite is used if it is not defined
+	Timeout *Duration `json:"timeout,omitempty"`
+
+	// FollowRedirects defines whether redirects are followed
+	// It can be a bool or max number of redirects
+	// Policy of client is used if it is not defined
+	FollowRedirects *FollowRedirects `json:"followRedirects,omitempty"`
+
+	// Compress defines content encoding of body, gzip or deflate
+	// Body will be compressed and Content-Encoding will be set
+	Compress string `json:"compress,omitempty"`
+
+	// Form defines fields of an application/x-www-form-urlencoded body
+	// Value can be a template or a list of templates
+	Form Values `json:"form,omitempty"`
+
+	// Multipart defines a multipart/form-data body
+	Multipart *Multipart `json:"multipart,omitempty"`
+
+	// BaseDir defines directory of the file which defines the request
+	// Relative paths of files in request are relative to it
+	BaseDir string `json:"-"`
+}
+
+// Multipart defines a multipart/form-data body
+type Multipart struct {
+	// Fields defines form fields of body
+	// Value can be a template or a list of templates
+	Fields Values `json:"fields,omitempty"`
+
+	// Files defines files which will be uploaded
+	Files []MultipartFile `json:"files,omitempty"`
+}
+
+// MultipartFile defines a file part of multipart body
+type MultipartFile struct {
+	// Name defines form field name of the file
+	Name string `json:"name"`
+
+	// Path defines path of the file
+	// Relative path is relative to the case file
+	Path *Template `json:"path"`
+
+	// Filename defines filename in Content-Disposition
+	// Default is base name of path
+	Filename string `json:"filename,omitempty"`
+
+	// ContentType defines Content-Type of the part
+	// Default is application/octet-stream
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// Values defines a map of templates
+type Values map[string]Templates
+
+// Templates defines a list of templates
+// It can be unmarshaled from a template or a list of templates
+type Templates []Template
+
+// UnmarshalJSON implements json.Unmarshaler
+func (ts *Templates) UnmarshalJSON(body []byte) error {
+	var values []interface{}
+	if err := json.Unmarshal(body, &values); err != nil {
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err != nil {
+			return err
+		}
+		values = []interface{}{value}
+	}
+	list := make(Templates, 0, len(values))
+	for _, v := range values {
+		// numbers and bools are allowed because values are encoded as text
+		switch v.(type) {
+		case string, float64, bool:
+		default:
+			return fmt.Errorf("value MUST be a string, a number or a bool, actual: %v", v)
+		}
+		t, err := NewTemplate(fmt.Sprint(v))
+		if err != nil {
+			return err
+		}
+		list = append(list, *t)
+	}
+	*ts = list
+	return nil
 }
 
 // Response defines a http response checker
 type Response struct {
 	// StatusCode checks response code
-	StatusCode int `json:"statusCode"`
+	StatusCode *StatusCode `json:"statusCode,omitempty"`
 
-	// Headers defines http header of request
-	// NOTE(liubog2008): whether to use map[string][]string
-	Headers map[string]Template `json:"headers,omitempty"`
+	// Headers defines matchers of http header of response
+	// Value can be a string or a matcher like body, e.g. {"$regexp": "^a"}
+	// Matchers of $contains, $unordered, $each and $any
+	// match all values of the header, others match the first value
+	Headers map[string]HeaderMatcher `json:"headers,omitempty"`
+
+	// Cookies defines matchers of cookies set by response
+	// Value can be a string which matches value of cookie or
+	// an object which matches attributes of cookie, e.g.
+	// {"value": {"$regexp": "^a"}, "httpOnly": true, "sameSite": "Lax"}
+	Cookies map[string]Template `json:"cookies,omitempty"`
 
 	// Body is also a template like request body
 	// It can be used to generate a matcher which
 	// can test response body
 	Body *Template `json:"body,omitempty"`
 
+	// BodyFile defines a file which contains template of body
+	// Relative path is relative to the case file
+	BodyFile string `json:"bodyFile,omitempty"`
+
+	// Redirects defines a matcher of followed redirects like body
+	// It matches an array of hops, each hop has url, statusCode and location
+	Redirects *Template `json:"redirects,omitempty"`
+
+	// TLS defines a matcher of tls connection state like body, e.g.
+	// {"version": "TLS 1.3", "peerCertificates": [{"dnsNames": {"$contains": ["a.com"]}}]}
+	TLS *Template `json:"tls,omitempty"`
+
+	// Encoding defines expected content encoding chosen by server
+	// e.g. gzip, deflate or identity
+	Encoding string `json:"encoding,omitempty"`
+
+	// Proto defines expected protocol negotiated with server
+	// e.g. HTTP/1.1 or HTTP/2.0
+	Proto string `json:"proto,omitempty"`
+
+	// Binary defines checks of a binary body, e.g. a tarball or an image
+	Binary *Binary `json:"binary,omitempty"`
+
+	// SaveTo defines name of a variable
+	// Body will be saved to a temp file and the variable holds its path
+	SaveTo string `json:"saveTo,omitempty"`
+
 	// Eventually defines an async checker for response
 	// It means response will eventually be matched
 	Eventually *Eventually `json:"eventually,omitempty"`
+
+	// ExpectTimeout defines that request is expected to time out
+	// e.g. server holds a long polling request
+	ExpectTimeout bool `json:"expectTimeout,omitempty"`
+}
+
+// DefaultMaxRedirects defines max number of redirects if followRedirects is true
+const DefaultMaxRedirects = 10
+
+// FollowRedirects defines max number of redirects which are followed
+// It can be unmarshaled from a bool or a number
+// true means DefaultMaxRedirects and false means 0
+type FollowRedirects int
+
+// UnmarshalJSON implements json.Unmarshaler
+func (f *FollowRedirects) UnmarshalJSON(body []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case bool:
+		*f = 0
+		if value {
+			*f = DefaultMaxRedirects
+		}
+		return nil
+	case float64:
+		if value >= 0 && value == float64(int(value)) {
+			*f = FollowRedirects(value)
+			return nil
+		}
+	}
+	return fmt.Errorf("followRedirects MUST be a bool or a non-negative integer, actual: %v", string(body))
+}
+
+// Binary defines checks of a binary body
+type Binary struct {
+	// Length defines expected length of body in bytes
+	Length *int64 `json:"length,omitempty"`
+
+	// SHA256 defines expected sha256 checksum of body in hex
+	SHA256 *Template `json:"sha256,omitempty"`
+
+	// MD5 defines expected md5 checksum of body in hex
+	MD5 *Template `json:"md5,omitempty"`
+
+	// ContentType defines expected content type which is detected from
+	// leading bytes of body, e.g. image/png or application/x-gzip
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// StatusCode defines expected status code of response
+// It can be a code (200), a list ([200, 204]), a class ("2xx"),
+// a range ("400-499") or a special matcher object ({"$not": 500})
+// 0 means status code is not checked
+type StatusCode struct {
+	raw []byte
+}
+
+// Raw returns json expression of status code
+func (s *StatusCode) Raw() []byte {
+	return s.raw
+}
+
+// MarshalJSON implements json.Marshaler
+func (s *StatusCode) MarshalJSON() ([]byte, error) {
+	return s.raw, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *StatusCode) UnmarshalJSON(body []byte) error {
+	if !json.Valid(body) {
+		return fmt.Errorf("invalid status code: %v", string(body))
+	}
+	if strings.TrimSpace(string(body)) == "0" {
+		s.raw = nil
+		return nil
+	}
+	s.raw = append([]byte(nil), body...)
+	return nil
+}
+
+// Strict defines strict mode of matching response body
+// In strict mode, fields of object in response body which
+// are not defined in expected body will be rejected
+type Strict struct {
+	// Enabled defines whether strict mode is enabled
+	Enabled bool `json:"enabled"`
+
+	// AllowedFields defines paths of fields which are allowed
+	// in strict mode, e.g. "metadata.resourceVersion"
+	AllowedFields []string `json:"allowedFields,omitempty"`
 }
 
 // When defines round trip condition
@@ -125,6 +361,18 @@ func (d *Duration) UnmarshalJSON(body []byte) error {
 	return nil
 }
 
+// NewTemplate returns a template parsed from raw string
+func NewTemplate(raw string) (*Template, error) {
+	templ, err := template.New(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{
+		Template: templ,
+		raw:      []byte(raw),
+	}, nil
+}
+
 // MarshalJSON implements json.Marshaler
 func (t *Template) MarshalJSON() ([]byte, error) {
 	return t.raw, nil
@@ -144,3 +392,37 @@ func (t *Template) UnmarshalJSON(body []byte) error {
 	t.raw = []byte(s)
 	return nil
 }
+
+// HeaderMatcher defines expectation of a response header
+// It is a string which matches value of header, or an object
+// of special matchers like body, e.g. {"$regexp": "^a"}
+type HeaderMatcher struct {
+	Template
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (h *HeaderMatcher) UnmarshalJSON(body []byte) error {
+	trimmed := strings.TrimSpace(string(body))
+	if !strings.HasPrefix(trimmed, "{") {
+		return h.Template.UnmarshalJSON(body)
+	}
+	obj := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return err
+	}
+	special := false
+	for k := range obj {
+		if strings.HasPrefix(k, "$") {
+			special = true
+		}
+	}
+	if !special {
+		return fmt.Errorf("header matcher MUST be a string or an object of special matchers, actual: %v", trimmed)
+	}
+	t, err := NewTemplate(trimmed)
+	if err != nil {
+		return err
+	}
+	h.Template = *t
+	return nil
+}
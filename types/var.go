@@ -13,7 +13,16 @@ type Var struct {
 type Definition struct {
 	Var `json:",inline"`
 	// Type defines variable from
-	// enum ["body", "status", "header"]
+	// enum ["body", "status", "header", "cookie", "headers", "response", "tls", "redirects"]
 	// default is body
 	Type string `json:"type,omitempty"`
+
+	// Regexp defines a pattern applied to the raw value, e.g. raw body
+	// or header value. The first capture group is used as variable value,
+	// whole match is used if there is no group
+	Regexp string `json:"regexp,omitempty"`
+
+	// Default defines value of variable if regexp is not matched
+	// or the capture group captures nothing
+	Default *Template `json:"default,omitempty"`
 }
@@ -0,0 +1,76 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	// MaxLines defines max number of lines of diff
+	MaxLines = 100
+
+	// MaxLineLength defines max length of a line of diff
+	MaxLineLength = 256
+
+	// ContextLines defines number of unchanged lines around changes
+	ContextLines = 3
+)
+
+const (
+	red   = "\x1b[31m"
+	green = "\x1b[32m"
+	cyan  = "\x1b[36m"
+	reset = "\x1b[0m"
+)
+
+// Unified returns unified diff between expected and actual text
+// Deleted lines are expected and inserted lines are actual
+// Long lines and huge diff are truncated
+func Unified(expected, actual string, color bool) (string, error) {
+	ud := difflib.UnifiedDiff{
+		A:        difflib.SplitLines(expected),
+		B:        difflib.SplitLines(actual),
+		FromFile: "expected",
+		ToFile:   "actual",
+		Context:  ContextLines,
+	}
+	s, err := difflib.GetUnifiedDiffString(ud)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	more := 0
+	if len(lines) > MaxLines {
+		more = len(lines) - MaxLines
+		lines = lines[:MaxLines]
+	}
+	for i, line := range lines {
+		if len(line) > MaxLineLength {
+			line = line[:MaxLineLength] + "...(truncated)"
+		}
+		if color {
+			line = colorize(line)
+		}
+		lines[i] = line
+	}
+	if more > 0 {
+		lines = append(lines, fmt.Sprintf("... %v more lines are truncated", more))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func colorize(line string) string {
+	switch {
+	case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+		return line
+	case strings.HasPrefix(line, "-"):
+		return red + line + reset
+	case strings.HasPrefix(line, "+"):
+		return green + line + reset
+	case strings.HasPrefix(line, "@@"):
+		return cyan + line + reset
+	}
+	return line
+}
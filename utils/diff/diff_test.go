@@ -0,0 +1,47 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnified(t *testing.T) {
+	expected := "{\n  \"a\": 1,\n  \"b\": 2\n}\n"
+	actual := "{\n  \"a\": 1,\n  \"b\": 3\n}\n"
+
+	d, err := Unified(expected, actual, false)
+	require.NoError(t, err)
+	assert.Contains(t, d, "--- expected")
+	assert.Contains(t, d, "+++ actual")
+	assert.Contains(t, d, "-  \"b\": 2")
+	assert.Contains(t, d, "+  \"b\": 3")
+
+	d, err = Unified(expected, actual, true)
+	require.NoError(t, err)
+	assert.Contains(t, d, red+"-  \"b\": 2"+reset)
+	assert.Contains(t, d, green+"+  \"b\": 3"+reset)
+
+	d, err = Unified(expected, expected, false)
+	require.NoError(t, err)
+	assert.Equal(t, "", d)
+}
+
+func TestUnifiedTruncate(t *testing.T) {
+	var expected, actual []string
+	for i := 0; i < 2*MaxLines; i++ {
+		expected = append(expected, fmt.Sprintf("%v", i))
+		actual = append(actual, fmt.Sprintf("%v%v", i, strings.Repeat("a", MaxLineLength)))
+	}
+	d, err := Unified(strings.Join(expected, "\n"), strings.Join(actual, "\n"), false)
+	require.NoError(t, err)
+	lines := strings.Split(d, "\n")
+	assert.Len(t, lines, MaxLines+1)
+	assert.Contains(t, lines[MaxLines], "more lines are truncated")
+	for _, line := range lines {
+		assert.True(t, len(line) <= MaxLineLength+len("...(truncated)"), line)
+	}
+}
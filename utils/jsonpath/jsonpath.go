@@ -0,0 +1,830 @@
+package jsonpath
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path is a compiled JSONPath expression
+// Supported syntax:
+//
+//	$                   root object
+//	.name, ['name']     child by name, ['a','b'] selects multiple children
+//	[n]                 element of array, negative index counts from the end
+//	[start:end:step]    slice of array
+//	.*, [*]             all children
+//	..name, ..*         recursive descent
+//	[?(@.a == 'b')]     filter by expression, supports ==, !=, <, <=, >, >=,
+//	                    =~ (regexp), !, &&, || and parentheses
+type Path struct {
+	expr     string
+	segments []segment
+}
+
+// IsJSONPath returns true if expr is a JSONPath expression
+func IsJSONPath(expr string) bool {
+	return strings.HasPrefix(expr, "$")
+}
+
+// Parse compiles a JSONPath expression
+func Parse(expr string) (*Path, error) {
+	p := &parser{
+		expr: expr,
+	}
+	if !p.consume("$") {
+		return nil, fmt.Errorf("jsonpath %q MUST start with $", expr)
+	}
+	segs, err := p.parseSegments()
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf("unexpected character %q", p.peek())
+	}
+	return &Path{
+		expr:     expr,
+		segments: segs,
+	}, nil
+}
+
+// String returns the original expression
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Definite returns true if path selects at most one value
+func (p *Path) Definite() bool {
+	return isDefinite(p.segments)
+}
+
+// Find returns all values selected by path
+func (p *Path) Find(obj interface{}) []interface{} {
+	return find(p.segments, obj, obj)
+}
+
+// Select returns value selected by path
+// If path is definite, the selected value is returned and it is an error if nothing is selected
+// Otherwise, an array of all selected values is returned
+func (p *Path) Select(obj interface{}) (interface{}, error) {
+	res := p.Find(obj)
+	if !p.Definite() {
+		if res == nil {
+			res = []interface{}{}
+		}
+		return res, nil
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("jsonpath %v selects nothing", p.expr)
+	}
+	return res[0], nil
+}
+
+// Select selects value from obj by a JSONPath expression
+func Select(obj interface{}, expr string) (interface{}, error) {
+	p, err := Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Select(obj)
+}
+
+func isDefinite(segs []segment) bool {
+	for _, seg := range segs {
+		if seg.recursive || !seg.selector.definite() {
+			return false
+		}
+	}
+	return true
+}
+
+func find(segs []segment, cur, root interface{}) []interface{} {
+	values := []interface{}{cur}
+	for _, seg := range segs {
+		next := []interface{}{}
+		for _, v := range values {
+			if !seg.recursive {
+				next = append(next, seg.selector.apply(v, root)...)
+				continue
+			}
+			for _, d := range descendants(v) {
+				next = append(next, seg.selector.apply(d, root)...)
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// descendants returns v and all of its descendants
+func descendants(v interface{}) []interface{} {
+	res := []interface{}{v}
+	for _, child := range children(v) {
+		res = append(res, descendants(child)...)
+	}
+	return res
+}
+
+// children returns children of object ordered by key or elements of array
+func children(v interface{}) []interface{} {
+	switch obj := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		res := make([]interface{}, 0, len(obj))
+		for _, k := range keys {
+			res = append(res, obj[k])
+		}
+		return res
+	case []interface{}:
+		return obj
+	}
+	return nil
+}
+
+type segment struct {
+	recursive bool
+	selector  selector
+}
+
+type selector interface {
+	apply(v, root interface{}) []interface{}
+	definite() bool
+}
+
+type nameSelector []string
+
+func (s nameSelector) apply(v, root interface{}) []interface{} {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	res := []interface{}{}
+	for _, name := range s {
+		if child, ok := obj[name]; ok {
+			res = append(res, child)
+		}
+	}
+	return res
+}
+
+func (s nameSelector) definite() bool {
+	return len(s) == 1
+}
+
+type indexSelector []int
+
+func (s indexSelector) apply(v, root interface{}) []interface{} {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	res := []interface{}{}
+	for _, i := range s {
+		if i < 0 {
+			i += len(arr)
+		}
+		if i >= 0 && i < len(arr) {
+			res = append(res, arr[i])
+		}
+	}
+	return res
+}
+
+func (s indexSelector) definite() bool {
+	return len(s) == 1
+}
+
+type sliceSelector struct {
+	start, end *int
+	step       int
+}
+
+func (s *sliceSelector) apply(v, root interface{}) []interface{} {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	n := len(arr)
+	normalize := func(i *int, def int) int {
+		if i == nil {
+			return def
+		}
+		k := *i
+		if k < 0 {
+			k += n
+		}
+		if k < 0 {
+			k = -1
+			if s.step > 0 {
+				k = 0
+			}
+		}
+		if k > n {
+			k = n
+			if s.step < 0 {
+				k = n - 1
+			}
+		}
+		return k
+	}
+	res := []interface{}{}
+	if s.step > 0 {
+		for i := normalize(s.start, 0); i < normalize(s.end, n); i += s.step {
+			res = append(res, arr[i])
+		}
+		return res
+	}
+	for i := normalize(s.start, n-1); i > normalize(s.end, -1); i += s.step {
+		if i < n {
+			res = append(res, arr[i])
+		}
+	}
+	return res
+}
+
+func (s *sliceSelector) definite() bool {
+	return false
+}
+
+type wildcardSelector struct{}
+
+func (s wildcardSelector) apply(v, root interface{}) []interface{} {
+	return children(v)
+}
+
+func (s wildcardSelector) definite() bool {
+	return false
+}
+
+type filterSelector struct {
+	expr expression
+}
+
+func (s *filterSelector) apply(v, root interface{}) []interface{} {
+	res := []interface{}{}
+	for _, child := range children(v) {
+		if s.expr.eval(child, root) {
+			res = append(res, child)
+		}
+	}
+	return res
+}
+
+func (s *filterSelector) definite() bool {
+	return false
+}
+
+type expression interface {
+	eval(cur, root interface{}) bool
+}
+
+type orExpr []expression
+
+func (e orExpr) eval(cur, root interface{}) bool {
+	for _, sub := range e {
+		if sub.eval(cur, root) {
+			return true
+		}
+	}
+	return false
+}
+
+type andExpr []expression
+
+func (e andExpr) eval(cur, root interface{}) bool {
+	for _, sub := range e {
+		if !sub.eval(cur, root) {
+			return false
+		}
+	}
+	return true
+}
+
+type notExpr struct {
+	expr expression
+}
+
+func (e *notExpr) eval(cur, root interface{}) bool {
+	return !e.expr.eval(cur, root)
+}
+
+// existExpr is true if operand exists and is not false or null
+type existExpr struct {
+	operand operand
+}
+
+func (e *existExpr) eval(cur, root interface{}) bool {
+	v, ok := e.operand.value(cur, root)
+	if !ok {
+		return false
+	}
+	switch b := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return b
+	}
+	return true
+}
+
+type compareExpr struct {
+	op          string
+	left, right operand
+	re          *regexp.Regexp
+}
+
+func (e *compareExpr) eval(cur, root interface{}) bool {
+	l, ok := e.left.value(cur, root)
+	if !ok {
+		return false
+	}
+	if e.re != nil {
+		s, ok := l.(string)
+		return ok && e.re.MatchString(s)
+	}
+	r, ok := e.right.value(cur, root)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "==":
+		return equal(l, r)
+	case "!=":
+		return !equal(l, r)
+	}
+	c, ok := compare(l, r)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+func equal(l, r interface{}) bool {
+	if lf, ok := toFloat(l); ok {
+		rf, ok := toFloat(r)
+		return ok && lf == rf
+	}
+	return reflect.DeepEqual(l, r)
+}
+
+func compare(l, r interface{}) (int, bool) {
+	if lf, ok := toFloat(l); ok {
+		rf, ok := toFloat(r)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case lf < rf:
+			return -1, true
+		case lf > rf:
+			return 1, true
+		}
+		return 0, true
+	}
+	ls, lok := l.(string)
+	rs, rok := r.(string)
+	if !lok || !rok {
+		return 0, false
+	}
+	return strings.Compare(ls, rs), true
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+type operand interface {
+	value(cur, root interface{}) (interface{}, bool)
+}
+
+type literal struct {
+	v interface{}
+}
+
+func (l *literal) value(cur, root interface{}) (interface{}, bool) {
+	return l.v, true
+}
+
+// pathOperand is a path relative to current node(@) or root($)
+type pathOperand struct {
+	root     bool
+	segments []segment
+}
+
+func (p *pathOperand) value(cur, root interface{}) (interface{}, bool) {
+	start := cur
+	if p.root {
+		start = root
+	}
+	res := find(p.segments, start, root)
+	if isDefinite(p.segments) {
+		if len(res) == 0 {
+			return nil, false
+		}
+		return res[0], true
+	}
+	return res, len(res) > 0
+}
+
+type parser struct {
+	expr string
+	pos  int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.expr)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.expr[p.pos]
+}
+
+func (p *parser) consume(s string) bool {
+	if strings.HasPrefix(p.expr[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(s string) error {
+	p.skipSpaces()
+	if !p.consume(s) {
+		return p.errorf("expected %q", s)
+	}
+	return nil
+}
+
+func (p *parser) skipSpaces() {
+	for !p.eof() && p.peek() == ' ' {
+		p.pos++
+	}
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid jsonpath %q at %v: %v", p.expr, p.pos, fmt.Sprintf(format, args...))
+}
+
+// parseSegments parses segments until a character which can't begin a segment
+func (p *parser) parseSegments() ([]segment, error) {
+	segs := []segment{}
+	for !p.eof() {
+		recursive := false
+		var sel selector
+		var err error
+		switch {
+		case p.consume(".."):
+			recursive = true
+			if p.peek() == '[' {
+				sel, err = p.parseBracket()
+			} else {
+				sel, err = p.parseDotName()
+			}
+		case p.consume("."):
+			sel, err = p.parseDotName()
+		case p.peek() == '[':
+			sel, err = p.parseBracket()
+		default:
+			return segs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		segs = append(segs, segment{
+			recursive: recursive,
+			selector:  sel,
+		})
+	}
+	return segs, nil
+}
+
+func (p *parser) parseDotName() (selector, error) {
+	if p.consume("*") {
+		return wildcardSelector{}, nil
+	}
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(".[]()=!<>&|,'\" ", rune(p.peek())) {
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, p.errorf("expected name")
+	}
+	return nameSelector{p.expr[start:p.pos]}, nil
+}
+
+func (p *parser) parseBracket() (selector, error) {
+	p.consume("[")
+	p.skipSpaces()
+	var sel selector
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		sel = wildcardSelector{}
+	case c == '?':
+		p.pos++
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		sel = &filterSelector{expr: expr}
+	case c == '\'' || c == '"':
+		names := nameSelector{}
+		for {
+			p.skipSpaces()
+			s, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, s)
+			p.skipSpaces()
+			if !p.consume(",") {
+				break
+			}
+		}
+		sel = names
+	default:
+		s, err := p.parseIndexOrSlice()
+		if err != nil {
+			return nil, err
+		}
+		sel = s
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return sel, nil
+}
+
+func (p *parser) parseIndexOrSlice() (selector, error) {
+	first, err := p.parseOptionalInt()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.peek() == ':' {
+		s := &sliceSelector{
+			start: first,
+			step:  1,
+		}
+		p.pos++
+		if s.end, err = p.parseOptionalInt(); err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.consume(":") {
+			step, err := p.parseOptionalInt()
+			if err != nil {
+				return nil, err
+			}
+			if step != nil {
+				s.step = *step
+			}
+		}
+		if s.step == 0 {
+			return nil, p.errorf("step of slice can't be 0")
+		}
+		return s, nil
+	}
+	if first == nil {
+		return nil, p.errorf("expected index")
+	}
+	indexes := indexSelector{*first}
+	for {
+		p.skipSpaces()
+		if !p.consume(",") {
+			break
+		}
+		i, err := p.parseOptionalInt()
+		if err != nil {
+			return nil, err
+		}
+		if i == nil {
+			return nil, p.errorf("expected index")
+		}
+		indexes = append(indexes, *i)
+	}
+	return indexes, nil
+}
+
+func (p *parser) parseOptionalInt() (*int, error) {
+	p.skipSpaces()
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for !p.eof() && p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(p.expr[start:p.pos])
+	if err != nil {
+		return nil, p.errorf("invalid index: %v", err)
+	}
+	return &i, nil
+}
+
+func (p *parser) parseString() (string, error) {
+	quote := p.peek()
+	if quote != '\'' && quote != '"' {
+		return "", p.errorf("expected quoted string")
+	}
+	p.pos++
+	bs := []byte{}
+	for !p.eof() {
+		c := p.peek()
+		p.pos++
+		switch c {
+		case '\\':
+			if p.eof() {
+				return "", p.errorf("unclosed string")
+			}
+			bs = append(bs, p.peek())
+			p.pos++
+		case quote:
+			return string(bs), nil
+		default:
+			bs = append(bs, c)
+		}
+	}
+	return "", p.errorf("unclosed string")
+}
+
+func (p *parser) parseOr() (expression, error) {
+	exprs := orExpr{}
+	for {
+		e, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+		p.skipSpaces()
+		if !p.consume("||") {
+			break
+		}
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return exprs, nil
+}
+
+func (p *parser) parseAnd() (expression, error) {
+	exprs := andExpr{}
+	for {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+		p.skipSpaces()
+		if !p.consume("&&") {
+			break
+		}
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return exprs, nil
+}
+
+func (p *parser) parseUnary() (expression, error) {
+	p.skipSpaces()
+	switch {
+	case p.peek() == '!' && !strings.HasPrefix(p.expr[p.pos:], "!="):
+		p.pos++
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{expr: e}, nil
+	case p.consume("("):
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+	return p.parseComparison()
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "=~", "<", ">"}
+
+func (p *parser) parseComparison() (expression, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	op := ""
+	for _, o := range operators {
+		if p.consume(o) {
+			op = o
+			break
+		}
+	}
+	if op == "" {
+		return &existExpr{operand: left}, nil
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	e := &compareExpr{
+		op:    op,
+		left:  left,
+		right: right,
+	}
+	if op == "=~" {
+		l, ok := right.(*literal)
+		if !ok {
+			return nil, p.errorf("right operand of =~ MUST be a string")
+		}
+		s, ok := l.v.(string)
+		if !ok {
+			return nil, p.errorf("right operand of =~ MUST be a string")
+		}
+		if e.re, err = regexp.Compile(s); err != nil {
+			return nil, p.errorf("invalid regexp: %v", err)
+		}
+	}
+	return e, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	p.skipSpaces()
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		segs, err := p.parseSegments()
+		if err != nil {
+			return nil, err
+		}
+		return &pathOperand{
+			root:     c == '$',
+			segments: segs,
+		}, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &literal{v: s}, nil
+	case p.consume("true"):
+		return &literal{v: true}, nil
+	case p.consume("false"):
+		return &literal{v: false}, nil
+	case p.consume("null"):
+		return &literal{v: nil}, nil
+	}
+	start := p.pos
+	for !p.eof() && strings.ContainsRune("+-.0123456789eE", rune(p.peek())) {
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, p.errorf("expected operand")
+	}
+	f, err := strconv.ParseFloat(p.expr[start:p.pos], 64)
+	if err != nil {
+		return nil, p.errorf("invalid number: %v", err)
+	}
+	return &literal{v: f}, nil
+}
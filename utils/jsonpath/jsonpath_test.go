@@ -0,0 +1,95 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJSON = `{
+	"kind": "List",
+	"items": [
+		{"id": 1, "name": "foo", "labels": {"app": "a"}, "replicas": 3},
+		{"id": 2, "name": "bar", "labels": {"app": "b"}, "replicas": 1},
+		{"id": 3, "name": "baz", "replicas": 5}
+	],
+	"meta": {"total": 3, "owner": {"name": "admin"}}
+}`
+
+func TestSelect(t *testing.T) {
+	cases := []struct {
+		desc     string
+		expr     string
+		expected string
+		hasError bool
+	}{
+		{"root", "$", "", false},
+		{"child", "$.kind", `"List"`, false},
+		{"bracket child", "$['meta']['total']", `3`, false},
+		{"index", "$.items[0].name", `"foo"`, false},
+		{"negative index", "$.items[-1].name", `"baz"`, false},
+		{"multiple indexes", "$.items[0,2].id", `[1, 3]`, false},
+		{"slice", "$.items[1:].id", `[2, 3]`, false},
+		{"slice with step", "$.items[::2].id", `[1, 3]`, false},
+		{"reversed slice", "$.items[::-1].id", `[3, 2, 1]`, false},
+		{"wildcard", "$.items[*].id", `[1, 2, 3]`, false},
+		{"dot wildcard", "$.meta.*", `[{"name": "admin"}, 3]`, false},
+		{"recursive", "$..name", `["foo", "bar", "baz", "admin"]`, false},
+		{"filter equal", "$.items[?(@.name == 'foo')].id", `[1]`, false},
+		{"filter compare", "$.items[?(@.replicas >= 3)].id", `[1, 3]`, false},
+		{"filter and", "$.items[?(@.replicas > 1 && @.name != 'foo')].id", `[3]`, false},
+		{"filter or", `$.items[?(@.name == "bar" || @.id == 3)].id`, `[2, 3]`, false},
+		{"filter exists", "$.items[?(@.labels)].id", `[1, 2]`, false},
+		{"filter not exists", "$.items[?(!@.labels)].id", `[3]`, false},
+		{"filter nested", "$.items[?(@.labels.app == 'b')].name", `["bar"]`, false},
+		{"filter regexp", "$.items[?(@.name =~ '^ba')].id", `[2, 3]`, false},
+		{"filter root", "$.items[?(@.replicas == $.meta.total)].name", `["foo"]`, false},
+		{"filter parentheses", "$.items[?((@.id == 1 || @.id == 2) && @.replicas > 1)].name", `["foo"]`, false},
+		{"indefinite selects nothing", "$.items[?(@.id > 10)]", `[]`, false},
+		{"definite selects nothing", "$.items[10]", ``, true},
+		{"missing $", "items", ``, true},
+		{"unclosed bracket", "$.items[0", ``, true},
+		{"unclosed filter", "$.items[?(@.id == 1]", ``, true},
+		{"zero step", "$.items[::0]", ``, true},
+	}
+
+	var obj interface{}
+	require.NoError(t, json.Unmarshal([]byte(testJSON), &obj))
+	for _, c := range cases {
+		res, err := Select(obj, c.expr)
+		if c.hasError {
+			assert.Error(t, err, c.desc)
+			continue
+		}
+		require.NoError(t, err, c.desc)
+		if c.expected == "" {
+			assert.Equal(t, obj, res, c.desc)
+			continue
+		}
+		var expected interface{}
+		require.NoError(t, json.Unmarshal([]byte(c.expected), &expected), c.desc)
+		assert.Equal(t, expected, res, c.desc)
+	}
+}
+
+func TestDefinite(t *testing.T) {
+	cases := []struct {
+		expr     string
+		definite bool
+	}{
+		{"$", true},
+		{"$.items[0].name", true},
+		{"$['meta'].total", true},
+		{"$.items[*]", false},
+		{"$..name", false},
+		{"$.items[0,1]", false},
+		{"$.items[?(@.id)]", false},
+	}
+	for _, c := range cases {
+		p, err := Parse(c.expr)
+		require.NoError(t, err, c.expr)
+		assert.Equal(t, c.definite, p.Definite(), c.expr)
+	}
+}
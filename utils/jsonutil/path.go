@@ -0,0 +1,73 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/caicloud/aloe/utils/jsonpath"
+)
+
+// GetVariableByPath returns a variable selected by jsonpath from raw json
+func GetVariableByPath(rawJSON []byte, name string, path string) (Variable, error) {
+	var obj interface{}
+	if err := json.Unmarshal(rawJSON, &obj); err != nil {
+		return nil, fmt.Errorf("can't get variable %s from invalid json(%s): %v", name, rawJSON, err)
+	}
+	return selectPath(obj, name, path)
+}
+
+// SelectPath returns a variable selected by jsonpath from variable v
+func SelectPath(v Variable, name string, path string) (Variable, error) {
+	obj, err := toInterface(v)
+	if err != nil {
+		return nil, fmt.Errorf("can't get variable %s with jsonpath %v: %v", name, path, err)
+	}
+	return selectPath(obj, name, path)
+}
+
+func selectPath(obj interface{}, name string, path string) (Variable, error) {
+	res, err := jsonpath.Select(obj, path)
+	if err != nil {
+		return nil, fmt.Errorf("can't get variable %s: %v", name, err)
+	}
+	raw, err := json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf("can't get variable %s: %v", name, err)
+	}
+	return GetVariable(raw, name)
+}
+
+// toInterface converts variable to value of json.Unmarshal
+func toInterface(v Variable) (interface{}, error) {
+	switch vv := v.(type) {
+	case VariableMap:
+		obj := map[string]interface{}{}
+		for _, k := range vv.Keys() {
+			child, _ := vv.Get(k)
+			c, err := toInterface(child)
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = c
+		}
+		return obj, nil
+	case VariableArray:
+		arr := make([]interface{}, 0, vv.Len())
+		for i := 0; i < vv.Len(); i++ {
+			c, err := toInterface(vv.Get(i))
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, c)
+		}
+		return arr, nil
+	}
+	if v.Type() == StringType {
+		return v.String(), nil
+	}
+	var obj interface{}
+	if err := json.Unmarshal([]byte(v.String()), &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}